@@ -0,0 +1,146 @@
+package gjsonquery
+
+// Expr is a query built with Field, And, Or and Not.
+// Expr can be passed as query to DoesMatch, Compile and other functions taking query.
+type Expr interface {
+	// Query returns the query structure, object or list (when keys repeat) understood by DoesMatch.
+	Query() interface{}
+	entries() []canonicalEntry
+}
+
+// And joins expressions, all of them have to match.
+func And(exprs ...Expr) Expr {
+	var e andExpr
+	for _, expr := range exprs {
+		e = append(e, expr.entries()...)
+	}
+	return e
+}
+
+// Or joins expressions, at least one of them has to match.
+func Or(exprs ...Expr) Expr {
+	alternatives := make([]interface{}, len(exprs))
+	for i, expr := range exprs {
+		alternatives[i] = expr.Query()
+	}
+	return andExpr{{key: "$or", value: alternatives}}
+}
+
+// Not matches when expressions (joined with And) do not match.
+func Not(exprs ...Expr) Expr {
+	return andExpr{{key: "$not", value: And(exprs...).Query()}}
+}
+
+// andExpr is a list of query keys joined with AND.
+type andExpr []canonicalEntry
+
+func (e andExpr) entries() []canonicalEntry {
+	return e
+}
+
+// Query returns query object, list of single key objects is used when keys repeat.
+func (e andExpr) Query() interface{} {
+	object := make(map[string]interface{}, len(e))
+	for _, entry := range e {
+		if _, ok := object[entry.key]; ok {
+			items := make([]interface{}, len(e))
+			for i, entry := range e {
+				items[i] = map[string]interface{}{entry.key: entry.value}
+			}
+			return items
+		}
+		object[entry.key] = entry.value
+	}
+	return object
+}
+
+// FieldExpr holds comparators of a single column, see Field.
+type FieldExpr struct {
+	column      string
+	comparators []canonicalEntry
+}
+
+// Field starts expression on column, comparators are added with methods, e.g. Field("age").Gte(18).Lt(65).
+// Column is used as is, see JoinPath to build columns from literal keys.
+func Field(column string) FieldExpr {
+	return FieldExpr{column: column}
+}
+
+func (f FieldExpr) with(name string, expectation interface{}) FieldExpr {
+	comparators := make([]canonicalEntry, len(f.comparators), len(f.comparators)+1)
+	copy(comparators, f.comparators)
+	f.comparators = append(comparators, canonicalEntry{key: name, value: expectation})
+	return f
+}
+
+// Is adds "$is" comparator.
+func (f FieldExpr) Is(value interface{}) FieldExpr { return f.with("$is", value) }
+
+// IsNot adds "!$is" comparator.
+func (f FieldExpr) IsNot(value interface{}) FieldExpr { return f.with("!$is", value) }
+
+// In adds "$in" comparator.
+func (f FieldExpr) In(values ...interface{}) FieldExpr { return f.with("$in", values) }
+
+// NotIn adds "!$in" comparator.
+func (f FieldExpr) NotIn(values ...interface{}) FieldExpr { return f.with("!$in", values) }
+
+// Gt adds "$gt" comparator.
+func (f FieldExpr) Gt(value interface{}) FieldExpr { return f.with("$gt", value) }
+
+// Gte adds "$gte" comparator.
+func (f FieldExpr) Gte(value interface{}) FieldExpr { return f.with("$gte", value) }
+
+// Lt adds "$lt" comparator.
+func (f FieldExpr) Lt(value interface{}) FieldExpr { return f.with("$lt", value) }
+
+// Lte adds "$lte" comparator.
+func (f FieldExpr) Lte(value interface{}) FieldExpr { return f.with("$lte", value) }
+
+// Contains adds "$contains" comparator.
+func (f FieldExpr) Contains(value interface{}) FieldExpr { return f.with("$contains", value) }
+
+// Exists adds "$exists" comparator.
+func (f FieldExpr) Exists(exists bool) FieldExpr { return f.with("$exists", exists) }
+
+// Regex adds "$regex" comparator, flags are optional (see README).
+func (f FieldExpr) Regex(pattern string, flags string) FieldExpr {
+	if flags == "" {
+		return f.with("$regex", pattern)
+	}
+	return f.with("$regex", []interface{}{pattern, flags})
+}
+
+// StartsWith adds "$startsWith" comparator.
+func (f FieldExpr) StartsWith(prefix string) FieldExpr { return f.with("$startsWith", prefix) }
+
+// EndsWith adds "$endsWith" comparator.
+func (f FieldExpr) EndsWith(suffix string) FieldExpr { return f.with("$endsWith", suffix) }
+
+// IIs adds "$iis" comparator (case insensitive "$is").
+func (f FieldExpr) IIs(value string) FieldExpr { return f.with("$iis", value) }
+
+// entries returns single entry of the column unless the same comparator is used more than once.
+func (f FieldExpr) entries() []canonicalEntry {
+	var (
+		entries []canonicalEntry
+		current map[string]interface{}
+	)
+	for _, c := range f.comparators {
+		if _, ok := current[c.key]; ok || current == nil {
+			current = make(map[string]interface{})
+			entries = append(entries, canonicalEntry{key: f.column, value: current})
+		}
+		current[c.key] = c.value
+	}
+	if entries == nil {
+		// no comparator -> column has to exist
+		entries = []canonicalEntry{{key: f.column, value: map[string]interface{}{"$exists": true}}}
+	}
+	return entries
+}
+
+// Query returns the query structure.
+func (f FieldExpr) Query() interface{} {
+	return andExpr(f.entries()).Query()
+}
@@ -0,0 +1,144 @@
+package gjsonquery_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	. "github.com/szpakas/gjsonquery"
+)
+
+func TestBuilderQuery(t *testing.T) {
+	var tests = []struct {
+		symbol   string
+		expr     Expr
+		expected string
+	}{
+		{"aa", Field("a").Is(1), `{"a":{"$is":1}}`},
+		{"ab", Field("a").In(1, 2), `{"a":{"$in":[1,2]}}`},
+		{"ac", Field("a").Gte(18).Lt(65), `{"a":{"$gte":18,"$lt":65}}`},
+		{"ad", Field("a").Gt(1).Gt(2), `[{"a":{"$gt":1}},{"a":{"$gt":2}}]`},
+		{"ae", Field("a"), `{"a":{"$exists":true}}`},
+		{"af", Field("a").IsNot(1).NotIn(2, 3), `{"a":{"!$in":[2,3],"!$is":1}}`},
+		{"ag", Field("a").Regex("^x", ""), `{"a":{"$regex":"^x"}}`},
+		{"ah", Field("a").Regex("^x", "i"), `{"a":{"$regex":["^x","i"]}}`},
+		{"ai", Field("a").StartsWith("x").EndsWith("y").IIs("xy"), `{"a":{"$endsWith":"y","$iis":"xy","$startsWith":"x"}}`},
+		{"aj", Field("a").Contains("x").Exists(true).Lte(3), `{"a":{"$contains":"x","$exists":true,"$lte":3}}`},
+		// logical operators
+		{"ba", And(Field("a").Is(1), Field("b").Is(2)), `{"a":{"$is":1},"b":{"$is":2}}`},
+		{"bb", And(Field("a").Gt(1), Field("a").Lt(5)), `[{"a":{"$gt":1}},{"a":{"$lt":5}}]`},
+		{"bc", Or(Field("a").Is(1), Field("b").Is(2)), `{"$or":[{"a":{"$is":1}},{"b":{"$is":2}}]}`},
+		{"bd", Not(Field("a").Is(1)), `{"$not":{"a":{"$is":1}}}`},
+		{"be", And(), `{}`},
+		{"bf", And(Field("a").Is(1), Or(Field("b").Is(2)), Or(Field("c").Is(3))), `[{"a":{"$is":1}},{"$or":[{"b":{"$is":2}}]},{"$or":[{"c":{"$is":3}}]}]`},
+	}
+
+	for _, tc := range tests {
+		encoded, err := json.Marshal(tc.expr.Query())
+		if err != nil {
+			t.Errorf("[%s] unexpected error: %v", tc.symbol, err)
+			continue
+		}
+		if string(encoded) != tc.expected {
+			t.Errorf("[%s] query mismatch, got: %s, expected: %s", tc.symbol, encoded, tc.expected)
+		}
+	}
+}
+
+func TestBuilderImmutable(t *testing.T) {
+	base := Field("a").Gte(18)
+	adults := base.Lt(65)
+	seniors := base.Gte(65)
+
+	if got := base.Query(); !reflect.DeepEqual(got, map[string]interface{}{"a": map[string]interface{}{"$gte": 18}}) {
+		t.Errorf("base modified: %v", got)
+	}
+	if got := adults.Query(); !reflect.DeepEqual(got, map[string]interface{}{"a": map[string]interface{}{"$gte": 18, "$lt": 65}}) {
+		t.Errorf("adults mismatch: %v", got)
+	}
+	if got := seniors.Query(); !reflect.DeepEqual(got, []interface{}{
+		map[string]interface{}{"a": map[string]interface{}{"$gte": 18}},
+		map[string]interface{}{"a": map[string]interface{}{"$gte": 65}},
+	}) {
+		t.Errorf("seniors mismatch: %v", got)
+	}
+}
+
+func TestBuilderMatch(t *testing.T) {
+	expr := And(
+		Field("age").Gte(18).Lt(65),
+		Or(Field("country").In("PL", "DE"), Field("vip").Is(true)),
+		Not(Field("name").StartsWith("test")),
+	)
+	var tests = []struct {
+		symbol   string
+		data     map[string]interface{}
+		expected bool
+	}{
+		{"aa", map[string]interface{}{"age": 30.0, "country": "PL", "name": "john"}, true},
+		{"ab", map[string]interface{}{"age": 30.0, "country": "US", "vip": true, "name": "john"}, true},
+		{"ac", map[string]interface{}{"age": 30.0, "country": "US", "name": "john"}, false},
+		{"ad", map[string]interface{}{"age": 70.0, "country": "PL", "name": "john"}, false},
+		{"ae", map[string]interface{}{"age": 30.0, "country": "PL", "name": "tester"}, false},
+	}
+
+	compiled, err := Compile(expr)
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	for _, tc := range tests {
+		matched, err := DoesMatch(expr, tc.data)
+		if err != nil || matched != tc.expected {
+			t.Errorf("[%s] DoesMatch got: %v (%v), expected: %v", tc.symbol, matched, err, tc.expected)
+		}
+		matched, err = DoesMatch(expr.Query(), tc.data)
+		if err != nil || matched != tc.expected {
+			t.Errorf("[%s] DoesMatch on Query got: %v (%v), expected: %v", tc.symbol, matched, err, tc.expected)
+		}
+		matched, err = compiled.Match(tc.data)
+		if err != nil || matched != tc.expected {
+			t.Errorf("[%s] Match got: %v (%v), expected: %v", tc.symbol, matched, err, tc.expected)
+		}
+		encoded, _ := json.Marshal(tc.data)
+		matched, err = DoesMatchJSON(expr, encoded)
+		if err != nil || matched != tc.expected {
+			t.Errorf("[%s] DoesMatchJSON got: %v (%v), expected: %v", tc.symbol, matched, err, tc.expected)
+		}
+	}
+}
+
+func TestBuilderMixedWithMaps(t *testing.T) {
+	var tests = []struct {
+		symbol   string
+		query    map[string]interface{}
+		data     map[string]interface{}
+		expected bool
+	}{
+		{"aa", map[string]interface{}{"$or": Or(Field("a").Is(1), Field("b").Is(2))}, map[string]interface{}{"b": 2}, true},
+		{"ab", map[string]interface{}{"$or": Or(Field("a").Is(1), Field("b").Is(2))}, map[string]interface{}{"b": 3}, false},
+		{"ac", map[string]interface{}{"$or": Field("a").Is(1)}, map[string]interface{}{"a": 1}, true},
+		{"ad", map[string]interface{}{"$or": Field("a").Is(1)}, map[string]interface{}{"a": 2}, false},
+		{"ae", map[string]interface{}{"$and": Field("a").Gt(1), "b": 1}, map[string]interface{}{"a": 2, "b": 1}, true},
+		{"af", map[string]interface{}{"$not": Field("a").Is(1)}, map[string]interface{}{"a": 1}, false},
+		{"ag", map[string]interface{}{"$or": []interface{}{Field("a").Is(1), map[string]interface{}{"b": 2}}}, map[string]interface{}{"b": 2}, true},
+		{"ah", map[string]interface{}{"!$or": Field("a").Is(1)}, map[string]interface{}{"a": 1}, false},
+	}
+
+	for _, tc := range tests {
+		q, err := Compile(tc.query)
+		if err != nil {
+			t.Fatalf("[%s] unexpected compile error: %v", tc.symbol, err)
+		}
+		encoded, _ := json.Marshal(tc.data)
+		for name, match := range map[string]func() (bool, error){
+			"DoesMatch":     func() (bool, error) { return DoesMatch(tc.query, tc.data) },
+			"Query.Match":   func() (bool, error) { return q.Match(tc.data) },
+			"DoesMatchJSON": func() (bool, error) { return DoesMatchJSON(tc.query, encoded) },
+		} {
+			matched, err := match()
+			if err != nil || matched != tc.expected {
+				t.Errorf("[%s|%s] got: %v (%v), expected: %v", tc.symbol, name, matched, err, tc.expected)
+			}
+		}
+	}
+}
@@ -1,43 +1,36 @@
 package gjsonquery
 
 import (
-	"errors"
-	"fmt"
-	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
 )
 
-func comparatorIs(actual, expected interface{}) bool {
-	_d("[comparatorIs]\n\tactual: %#v \n\texpected: %#v\n", actual, expected)
-	if actual != expected {
-		_d("[comparatorIs] RETURN: False\n")
+// comparatorIs checks equality, numbers of different types are equal when they have the same value unless strict.
+func comparatorIs(actual, expected interface{}, strict bool) bool {
+	if !equal(actual, expected, strict) {
 		return false
 	}
-	_d("[comparatorIs] RETURN: True\n")
 	return true
 }
 
-func comparatorIn(actual, expected interface{}) (bool, error) {
+func comparatorIn(actual, expected interface{}, strict bool) (bool, error) {
 	eCasted, ok := expected.([]interface{})
 
-	_d("[comparatorIn]\n\tactual: %#v (%t|%#v)\n\texpected: %#v\n", actual, ok, eCasted, expected)
-
 	if !ok {
-		_d("[comparatorIn] ERROR: unknown expected type\n")
-		return false, errors.New("comparatorIn: unknown expected type")
+		return false, newQueryError("comparatorIn", ErrUnknownExpectedType, expected)
 	}
 
 	for _, e := range eCasted {
-		if e == actual {
-			_d("[comparatorIn] RETURN: True\n")
+		if equal(actual, e, strict) {
 			return true, nil
 		}
 	}
-	_d("[comparatorIn] RETURN: False\n")
 	return false, nil
 }
 
-func comparatorNot(actual, expected interface{}) (matched bool, err error) {
-	_d("[comparatorNot]\n\tactual: %#v \n\texpected: %#v\n", actual, expected)
+func comparatorNot(o *options, actual, expected interface{}) (matched bool, err error) {
 	// -- determine actual comparator
 	var cmp comparator
 	switch interface{}(expected).(type) {
@@ -47,79 +40,246 @@ func comparatorNot(actual, expected interface{}) (matched bool, err error) {
 		cmp = comparator{cType: COMPARATOR_IS, negated: false}
 	}
 
-	_d("[comparatorNot] triggered comparator: %#v\n", cmp)
-	matched, err = matchComparator(cmp, actual, expected)
+	matched, err = matchComparator(o, cmp, actual, true, expected)
 	if err != nil {
 		return false, err
 	}
 	return !matched, nil
 }
 
-func comparatorGeneric(cType comparatorType, actual, expected interface{}) (matched bool, err error) {
-	_d("[comparator]\n\tcType: %#v\n\tactual: %#v \n\texpected: %#v\n", cType, actual, expected)
-	isInt, aI, eI, aF, eF, err := castArguments(actual, expected)
+func comparatorContains(actual, expected interface{}, strict bool) (bool, error) {
+
+	switch actualCasted := actual.(type) {
+	// -- substring check
+	case string:
+		expectedCasted, ok := expected.(string)
+		if !ok {
+			return false, newQueryError("comparatorContains", ErrTypeMismatch, actual)
+		}
+		return strings.Contains(actualCasted, expectedCasted), nil
+	// -- membership check
+	case []interface{}:
+		for _, a := range actualCasted {
+			if comparatorIs(a, expected, strict) {
+				return true, nil
+			}
+		}
+		return false, nil
+	// -- missing value or null never contains anything
+	case nil:
+		return false, nil
+	}
+
+	return false, newQueryError("comparatorContains", ErrTypeMismatch, actual)
+}
+
+// comparatorExists checks presence of the key in data, null value is present.
+func comparatorExists(exists bool, expected interface{}) (bool, error) {
+	expectedCasted, ok := expected.(bool)
+	if !ok {
+		return false, newQueryError("comparatorExists", ErrUnknownExpectedType, expected)
+	}
+	return exists == expectedCasted, nil
+}
+
+// comparatorRegex checks string against regular expression (RE2 syntax).
+// Expectation is a pattern or a list of pattern and flags, e.g. ["^ab+c$", "i"].
+func comparatorRegex(actual, expected interface{}) (bool, error) {
+	re, err := compileRegex(expected)
 	if err != nil {
-		return
-	}
-
-	switch {
-	case cType == COMPARATOR_GT && isInt:
-		matched = aI > eI
-	case cType == COMPARATOR_GT && !isInt:
-		matched = aF > eF
-	case cType == COMPARATOR_GTE && isInt:
-		matched = aI >= eI
-	case cType == COMPARATOR_GTE && !isInt:
-		matched = aF >= eF
-	case cType == COMPARATOR_LT && isInt:
-		matched = aI < eI
-	case cType == COMPARATOR_LT && !isInt:
-		matched = aF < eF
-	case cType == COMPARATOR_LTE && isInt:
-		matched = aI <= eI
-	case cType == COMPARATOR_LTE && !isInt:
-		matched = aF <= eF
+		return false, err
+	}
+	return matchRegex(re, actual)
+}
+
+// regexFlags are flags accepted by "$regex": i - case insensitive, m - multi-line, s - "." matches "\n".
+const regexFlags = "ims"
+
+func compileRegex(expected interface{}) (*regexp.Regexp, error) {
+	var pattern, flags string
+	switch e := expected.(type) {
+	case string:
+		pattern = e
+	case []interface{}:
+		if len(e) != 2 {
+			return nil, newQueryError("comparatorRegex", ErrUnknownExpectedType, expected)
+		}
+		var ok bool
+		if pattern, ok = e[0].(string); !ok {
+			return nil, newQueryError("comparatorRegex", ErrUnknownExpectedType, expected)
+		}
+		if flags, ok = e[1].(string); !ok {
+			return nil, newQueryError("comparatorRegex", ErrUnknownExpectedType, expected)
+		}
 	default:
-		return false, errors.New("comparator: unknown comparator type")
+		return nil, newQueryError("comparatorRegex", ErrUnknownExpectedType, expected)
+	}
+
+	for _, flag := range flags {
+		if !strings.ContainsRune(regexFlags, flag) {
+			return nil, newQueryError("comparatorRegex", ErrInvalidPattern, expected)
+		}
+	}
+	if flags != "" {
+		pattern = "(?" + flags + ")" + pattern
+	}
+
+	re, err := cachedRegex(pattern)
+	if err != nil {
+		return nil, newQueryError("comparatorRegex", ErrInvalidPattern, expected)
+	}
+	return re, nil
+}
+
+// regexCacheSize limits number of patterns kept by regexCache.
+const regexCacheSize = 256
+
+// regexCache keeps compiled patterns, so DoesMatch compiles a pattern once instead of on every call and for every value
+// fetched with a wildcard. Patterns come from queries, so the cache is cleared when full instead of growing.
+var regexCache = struct {
+	sync.RWMutex
+	patterns map[string]*regexp.Regexp
+}{patterns: make(map[string]*regexp.Regexp)}
+
+// cachedRegex compiles pattern or returns the one compiled before, regexp.Regexp is safe for concurrent use.
+func cachedRegex(pattern string) (*regexp.Regexp, error) {
+	regexCache.RLock()
+	re, ok := regexCache.patterns[pattern]
+	regexCache.RUnlock()
+	if ok {
+		return re, nil
 	}
-	return
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	regexCache.Lock()
+	if len(regexCache.patterns) >= regexCacheSize {
+		regexCache.patterns = make(map[string]*regexp.Regexp)
+	}
+	regexCache.patterns[pattern] = re
+	regexCache.Unlock()
+	return re, nil
+}
+
+func matchRegex(re *regexp.Regexp, actual interface{}) (bool, error) {
+	switch actualCasted := actual.(type) {
+	case string:
+		return re.MatchString(actualCasted), nil
+	// -- missing value or null never matches
+	case nil:
+		return false, nil
+	}
+	return false, newQueryError("comparatorRegex", ErrTypeMismatch, actual)
 }
 
-func castArguments(actual, expected interface{}) (isInt bool, aI, eI int, aF, eF float64, err error) {
-	// We are using the "expected" type as required type and casting actual to it if necessary
+// comparatorString performs string comparisons ("$startsWith", "$endsWith", "$iis").
+// Both values have to be strings, missing value and null are reported as ErrTypeMismatch.
+func comparatorString(cType comparatorType, actual, expected interface{}) (bool, error) {
+	expectedCasted, ok := expected.(string)
+	if !ok {
+		return false, newQueryError("comparatorString", ErrUnknownExpectedType, expected)
+	}
+	actualCasted, ok := actual.(string)
+	if !ok {
+		return false, newQueryError("comparatorString", ErrTypeMismatch, actual)
+	}
+
+	switch cType {
+	case COMPARATOR_STARTS_WITH:
+		return strings.HasPrefix(actualCasted, expectedCasted), nil
+	case COMPARATOR_ENDS_WITH:
+		return strings.HasSuffix(actualCasted, expectedCasted), nil
+	case COMPARATOR_IIS:
+		return strings.EqualFold(actualCasted, expectedCasted), nil
+	}
+	return false, newQueryError("comparatorString", ErrUnknownComparator, nil)
+}
+
+// comparatorGeneric orders actual value against expected one.
+// Numbers are compared exactly (see compareNumeric), strings lexicographically (byte-wise)
+// and timestamps (time.Time or RFC 3339 strings) chronologically.
+func comparatorGeneric(cType comparatorType, actual, expected interface{}) (matched bool, err error) {
+	order, ok, err := compareOrdered(actual, expected)
+	if !ok && err == nil {
+		order, err = compareNumeric(actual, expected)
+	}
+	if err != nil {
+		return false, err
+	}
+	return matchOrder(cType, order)
+}
+
+// matchOrder converts result of comparison (-1, 0, +1) into result of the comparator.
+func matchOrder(cType comparatorType, order int) (bool, error) {
+	switch cType {
+	case COMPARATOR_GT:
+		return order > 0, nil
+	case COMPARATOR_GTE:
+		return order >= 0, nil
+	case COMPARATOR_LT:
+		return order < 0, nil
+	case COMPARATOR_LTE:
+		return order <= 0, nil
+	}
+	return false, newQueryError("comparator", ErrUnknownComparator, nil)
+}
+
+// compareOrdered compares strings and timestamps, ok is false when expected value is neither of them.
+// Two strings are compared chronologically only when both of them are RFC 3339 timestamps.
+func compareOrdered(actual, expected interface{}) (order int, ok bool, err error) {
 	switch expectedCasted := expected.(type) {
-	case int:
-		actualCastedToInt, ok := actual.(int)
-		if !ok {
-			actualCastedToFloat64, ok := actual.(float64)
-			if !ok {
-				err = errors.New("comparator: casting actual to Int failed.")
-				return
-			}
-			actualCastedToInt = int(actualCastedToFloat64)
+	case time.Time:
+		actualTime, isTime := toTime(actual)
+		if !isTime {
+			return 0, false, newQueryError("comparator", ErrTypeMismatch, actual)
 		}
-		// prepare output
-		isInt = true
-		aI = actualCastedToInt
-		eI = expectedCasted
-		return
-	case float64:
-		actualCastedToFloat64, ok := actual.(float64)
-		if !ok {
-			actualCastedToInt, ok := actual.(int)
-			if !ok {
-				err = errors.New("comparator: casting actual to Float64 failed.")
-				return
+		return actualTime.Compare(expectedCasted), true, nil
+	case string:
+		switch actualCasted := actual.(type) {
+		case string:
+			if actualTime, isTime := toTime(actualCasted); isTime {
+				if expectedTime, isTime := toTime(expectedCasted); isTime {
+					return actualTime.Compare(expectedTime), true, nil
+				}
+			}
+			return strings.Compare(actualCasted, expectedCasted), true, nil
+		case time.Time:
+			if expectedTime, isTime := toTime(expectedCasted); isTime {
+				return actualCasted.Compare(expectedTime), true, nil
 			}
-			actualCastedToFloat64 = float64(actualCastedToInt)
 		}
-		// prepare output
-		isInt = false
-		aF = actualCastedToFloat64
-		eF = expectedCasted
-		return
+		return 0, false, newQueryError("comparator", ErrTypeMismatch, actual)
 	}
+	return 0, false, nil
+}
+
+// toTime accepts time.Time and RFC 3339 strings (fractional seconds are optional).
+func toTime(v interface{}) (time.Time, bool) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, true
+	case string:
+		parsed, err := time.Parse(time.RFC3339Nano, t)
+		return parsed, err == nil
+	}
+	return time.Time{}, false
+}
 
-	err = errors.New(fmt.Sprintf("comparator: unknown type (type: %s)", reflect.TypeOf(expected)))
-	return
+// compareNumeric orders numbers of any Go numeric type, json.Number and *big.Int exactly, without casting nor overflow.
+// NaN can not be ordered: NaN expected is reported as ErrUnknownExpectedType, NaN in data as ErrNaN.
+func compareNumeric(actual, expected interface{}) (int, error) {
+	expectedNumber, ok := toNumber(expected)
+	if !ok || expectedNumber.isNaN() {
+		return 0, newQueryError("comparator", ErrUnknownExpectedType, expected)
+	}
+	actualNumber, ok := toNumber(actual)
+	if !ok {
+		return 0, newQueryError("comparator", ErrTypeMismatch, actual)
+	}
+	if actualNumber.isNaN() {
+		return 0, newQueryError("comparator", ErrNaN, actual)
+	}
+	return compareNumbers(actualNumber, expectedNumber), nil
 }
@@ -1,6 +1,11 @@
 package gjsonquery_test
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
 	"testing"
 
 	. "github.com/szpakas/gjsonquery"
@@ -8,7 +13,97 @@ import (
 
 func TestComparatorGeneric(t *testing.T) {
 	_, err := ComparatorGeneric(-999, 123, 456)
-	if err == nil && err.Error() != "comparator: unknown comparator type" {
+	if !errors.Is(err, ErrUnknownComparator) {
 		t.Error("Generic comparator should bail on unknown comparator type.")
 	}
 }
+
+func TestComparatorGenericNumbers(t *testing.T) {
+	maxUint := new(big.Int).SetUint64(math.MaxUint64)
+	aboveMaxUint := new(big.Int).Add(maxUint, big.NewInt(1))
+
+	var tests = []struct {
+		symbol   string
+		actual   interface{}
+		expected interface{}
+		// order of actual against expected: -1, 0 or 1
+		order int
+		err   error
+	}{
+		// no truncation
+		{"aa", 100.7, 100, 1, nil},
+		{"ab", -0.5, 0, -1, nil},
+		{"ac", 100, 100.5, -1, nil},
+		{"ad", 100.0, 100, 0, nil},
+		// all Go numeric types
+		{"ba", int8(-1), uint8(1), -1, nil},
+		{"bb", int16(5), float32(5), 0, nil},
+		{"bc", uint32(7), int64(7), 0, nil},
+		{"bd", float32(0.5), 0.5, 0, nil},
+		{"be", json.Number("12.5"), 12, 1, nil},
+		{"bf", 13, json.Number("12.5"), 1, nil},
+		// no overflow nor precision loss on large values
+		{"ca", int64(math.MaxInt64), float64(math.MaxInt64), -1, nil}, // float64 rounds up to 2^63
+		{"cb", uint64(math.MaxUint64), int64(-1), 1, nil},
+		{"cc", uint64(math.MaxUint64), float64(math.MaxUint64), -1, nil}, // float64 rounds up to 2^64
+		{"cd", uint64(1 << 63), int64(math.MaxInt64), 1, nil},
+		{"ce", int64(math.MinInt64), uint64(0), -1, nil},
+		{"cf", json.Number("9007199254740993"), 9007199254740992.0, 1, nil},
+		{"cg", json.Number("18446744073709551616"), uint64(math.MaxUint64), 1, nil},
+		{"ch", aboveMaxUint, float64(1 << 64), 0, nil},
+		{"ci", maxUint, uint64(math.MaxUint64), 0, nil},
+		{"cj", big.NewInt(-5), -4.5, -1, nil},
+		{"ck", math.Inf(1), aboveMaxUint, 1, nil},
+		{"cl", math.Inf(-1), math.Inf(-1), 0, nil},
+		// NaN
+		{"da", math.NaN(), 1, 0, ErrNaN},
+		{"db", 1, math.NaN(), 0, ErrUnknownExpectedType},
+		// not numbers
+		{"ea", "1", 1, 0, ErrTypeMismatch},
+		{"eb", nil, 1, 0, ErrTypeMismatch},
+		{"ec", 1, true, 0, ErrUnknownExpectedType},
+		{"ed", 1, (*big.Int)(nil), 0, ErrUnknownExpectedType},
+	}
+
+	for _, tc := range tests {
+		for cType, expected := range map[ComparatorType]bool{
+			COMPARATOR_GT:  tc.order > 0,
+			COMPARATOR_GTE: tc.order >= 0,
+			COMPARATOR_LT:  tc.order < 0,
+			COMPARATOR_LTE: tc.order <= 0,
+		} {
+			matched, err := ComparatorGeneric(cType, tc.actual, tc.expected)
+			if !sameError(tc.err, err) {
+				t.Errorf("[%s|%d] Mismatch on error => expected: %v, have: %v", tc.symbol, cType, tc.err, err)
+				continue
+			}
+			if err == nil && matched != expected {
+				t.Errorf("[%s|%d] Mismatch for %v against %v => expected: %t, have: %t", tc.symbol, cType, tc.actual, tc.expected, expected, matched)
+			}
+		}
+	}
+}
+
+func TestCompileRegexCache(t *testing.T) {
+	first, err := CompileRegex([]interface{}{"^cache-test$", "i"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, _ := CompileRegex([]interface{}{"^cache-test$", "i"})
+	if first != second {
+		t.Error("Pattern should be compiled once.")
+	}
+	if other, _ := CompileRegex("^cache-test$"); other == first {
+		t.Error("Flags should be a part of the cached pattern.")
+	}
+
+	// cache does not grow with patterns of many queries
+	for i := 0; i < 1000; i++ {
+		if _, err := CompileRegex(fmt.Sprintf("^x%d$", i)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if n := RegexCacheLen(); n > 256 {
+		t.Errorf("Cache should be limited => have: %d patterns", n)
+	}
+}
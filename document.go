@@ -0,0 +1,127 @@
+package gjsonquery
+
+// Document is a source of values for custom data types, e.g. lazily decoded messages or database rows.
+//
+// Get returns value at path of literal keys, list indexes are passed as decimal strings ("0", "-1").
+// Empty path refers to the whole document.
+// Returned values have to be plain (maps, lists, scalars) or Go values supported by DoesMatchValue.
+// Paths with PATH_WILDCARD are split: Get receives the part before the first wildcard and the rest is resolved on the returned value.
+type Document interface {
+	Get(path []string) (interface{}, bool)
+}
+
+// DoesMatchDocument reports whether document matches the query.
+func DoesMatchDocument(query interface{}, doc Document) (bool, error) {
+	return defaultMatcher.DoesMatchDocument(query, doc)
+}
+
+// DoesMatchDocument reports whether document matches the query, see DoesMatchDocument.
+// Built-in documents are matched as by DoesMatch, DoesMatchValue and DoesMatchJSON respectively.
+func (m *Matcher) DoesMatchDocument(query interface{}, doc Document) (bool, error) {
+	switch d := doc.(type) {
+	case MapDocument:
+		return m.DoesMatch(query, d)
+	case ValueDocument:
+		return m.DoesMatchValue(query, d.Value)
+	case JSONDocument:
+		return m.DoesMatchJSON(query, d)
+	}
+	// first level match is always AND
+	return matcherAnd(m.opts, query, doc)
+}
+
+// MatchDocument reports whether document matches the compiled query, see DoesMatchDocument.
+func (q *Query) MatchDocument(doc Document) (bool, error) {
+	switch d := doc.(type) {
+	case MapDocument:
+		return q.Match(d)
+	case ValueDocument:
+		return q.MatchValue(d.Value)
+	case JSONDocument:
+		return q.MatchJSON(d)
+	}
+	return q.root.match(q.opts, doc)
+}
+
+// MapDocument is a Document backed by data decoded by encoding/json.
+type MapDocument map[string]interface{}
+
+// Get returns value at path.
+func (d MapDocument) Get(path []string) (interface{}, bool) {
+	return documentValue(fetchPath(defaultOptions, map[string]interface{}(d), keysToPath(path)))
+}
+
+// ValueDocument is a Document backed by Go value (struct, pointer, map or slice), see DoesMatchValue.
+type ValueDocument struct {
+	Value interface{}
+}
+
+// Get returns value at path.
+func (d ValueDocument) Get(path []string) (interface{}, bool) {
+	return documentValue(fetchPath(defaultOptions, d.Value, keysToPath(path)))
+}
+
+// JSONDocument is a Document backed by raw JSON object, see DoesMatchJSON.
+// Every call of Get scans the document and decodes only the value at path.
+type JSONDocument []byte
+
+// Get returns value at path, false is returned for malformed documents too.
+func (d JSONDocument) Get(path []string) (interface{}, bool) {
+	elements := keysToPath(path)
+	s := &selector{}
+	s.addPath(elements)
+	data, err := decodeSelected(defaultOptions, s, d)
+	if err != nil {
+		return nil, false
+	}
+	return documentValue(fetchPath(defaultOptions, data, elements))
+}
+
+// documentValue converts value fetched by built-in documents into plain form.
+// Cyclic values are returned unconverted, so they are reported with ErrCyclicValue by comparators.
+func documentValue(v interface{}, found bool) (interface{}, bool) {
+	if plain, err := plainValue(v); err == nil {
+		return plain, found
+	}
+	return v, found
+}
+
+// keysToPath converts literal keys into path elements, numeric keys are list indexes as well.
+func keysToPath(keys []string) []pathElement {
+	path := make([]pathElement, len(keys))
+	for i, key := range keys {
+		path[i] = newPathElement(key)
+		path[i].wildcard = false
+	}
+	return path
+}
+
+// fetchDocument obtains value from custom Document, see fetchPath.
+func fetchDocument(o *options, doc Document, path []pathElement) (interface{}, bool) {
+	keys := make([]string, 0, len(path))
+	for i, el := range path {
+		if el.wildcard {
+			value, exists := doc.Get(keys)
+			if !exists {
+				return []interface{}(nil), false
+			}
+			results := fetchAll(o, value, path[i:], nil)
+			return results, len(results) > 0
+		}
+		keys = append(keys, el.key)
+	}
+
+	value, exists := doc.Get(keys)
+	if !exists {
+		return nil, false
+	}
+	return lazyValue(value), true
+}
+
+// wholeData returns data as a single value for comparators used in place of column.
+func wholeData(data interface{}) interface{} {
+	if doc, ok := data.(Document); ok {
+		data, _ = doc.Get([]string{})
+	}
+	return lazyValue(data)
+}
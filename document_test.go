@@ -0,0 +1,137 @@
+package gjsonquery_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/szpakas/gjsonquery"
+)
+
+// rowDocument mimics database row with flattened column names, e.g. "address.city".
+type rowDocument struct {
+	columns map[string]interface{}
+	gets    []string
+}
+
+func (d *rowDocument) Get(path []string) (interface{}, bool) {
+	column := strings.Join(path, ".")
+	d.gets = append(d.gets, column)
+	if column == "" {
+		return d.columns, true
+	}
+	v, ok := d.columns[column]
+	return v, ok
+}
+
+func TestDoesMatchDocument(t *testing.T) {
+	row := &rowDocument{columns: map[string]interface{}{
+		"name":         "Bob",
+		"address.city": "Lodz",
+		"orders":       []interface{}{map[string]interface{}{"total": 10}, map[string]interface{}{"total": 99}},
+		"deleted_at":   nil,
+	}}
+
+	var tests = []struct {
+		symbol   string
+		query    interface{}
+		expected bool
+		gets     []string
+	}{
+		{"aa", map[string]interface{}{"name": "Bob"}, true, []string{"name"}},
+		{"ab", map[string]interface{}{"address.city": []interface{}{"Lodz", "Sopot"}}, true, []string{"address.city"}},
+		{"ac", map[string]interface{}{"deleted_at": map[string]interface{}{"$exists": true}}, true, []string{"deleted_at"}},
+		{"ad", map[string]interface{}{"missing": map[string]interface{}{"$exists": true}}, false, []string{"missing"}},
+		// wildcard -> prefix is fetched from the document, the rest is resolved on the value
+		{"ba", map[string]interface{}{"orders.*.total": map[string]interface{}{"$gt": 50}}, true, []string{"orders"}},
+		{"bb", map[string]interface{}{"!orders.*": map[string]interface{}{"$exists": true}}, false, []string{"orders"}},
+		// comparator used in place of column gets the whole document
+		{"ca", map[string]interface{}{"$exists": true}, true, []string{""}},
+		{"cb", map[string]interface{}{"$is": nil}, false, []string{""}},
+	}
+
+	for _, tc := range tests {
+		q, err := Compile(tc.query)
+		if err != nil {
+			t.Fatalf("[%s] Compile failed: %s", tc.symbol, err)
+		}
+
+		for name, matched := range map[string]func() (bool, error){
+			"DoesMatchDocument":   func() (bool, error) { return DoesMatchDocument(tc.query, row) },
+			"Query.MatchDocument": func() (bool, error) { return q.MatchDocument(row) },
+		} {
+			row.gets = nil
+			result, err := matched()
+			if err != nil || result != tc.expected {
+				t.Errorf("[%s|%s] Mismatch => expected: %t, have: %t (err: %v)", tc.symbol, name, tc.expected, result, err)
+			}
+			if !reflect.DeepEqual(row.gets, tc.gets) {
+				t.Errorf("[%s|%s] Mismatch on Get calls => expected: %#v, have: %#v", tc.symbol, name, tc.gets, row.gets)
+			}
+		}
+	}
+}
+
+func TestBuiltInDocuments(t *testing.T) {
+	data := map[string]interface{}{
+		"a":     1.0,
+		"b":     map[string]interface{}{"c": []interface{}{"x", "y"}},
+		"*":     "star",
+		"d.e":   true,
+		"empty": nil,
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		t.Fatalf("Marshal failed: %s", err)
+	}
+
+	var tests = []struct {
+		symbol   string
+		path     []string
+		expected interface{}
+		found    bool
+	}{
+		{"aa", []string{"a"}, 1.0, true},
+		{"ab", []string{"b", "c", "1"}, "y", true},
+		{"ac", []string{"b", "c", "-2"}, "x", true},
+		{"ad", []string{"*"}, "star", true},
+		{"ae", []string{"d.e"}, true, true},
+		{"af", []string{"empty"}, nil, true},
+		{"ag", []string{}, data, true},
+		{"ba", []string{"x"}, nil, false},
+		{"bb", []string{"b", "c", "2"}, nil, false},
+		{"bc", []string{"a", "b"}, nil, false},
+	}
+
+	for name, doc := range map[string]Document{
+		"MapDocument":   MapDocument(data),
+		"ValueDocument": ValueDocument{Value: data},
+		"JSONDocument":  JSONDocument(raw),
+	} {
+		for _, tc := range tests {
+			value, found := doc.Get(tc.path)
+			if found != tc.found || !reflect.DeepEqual(value, tc.expected) {
+				t.Errorf("[%s|%s] Mismatch => expected: %#v (%t), have: %#v (%t)", name, tc.symbol, tc.expected, tc.found, value, found)
+			}
+		}
+	}
+}
+
+func TestDoesMatchBuiltInDocuments(t *testing.T) {
+	for _, tDef := range doesMatchTests {
+		for _, tCase := range tDef.tests {
+			expected, expectedErr := DoesMatch(tDef.query, tCase.data)
+
+			for name, doc := range map[string]Document{
+				"MapDocument":   MapDocument(tCase.data),
+				"ValueDocument": ValueDocument{Value: tCase.data},
+			} {
+				have, haveErr := DoesMatchDocument(tDef.query, doc)
+				if !sameResult(expected, expectedErr, have, haveErr) {
+					t.Errorf("[%s|%s|%s] Mismatch => expected: %t (err: %v), have: %t (err: %v)", tDef.symbol, tCase.symbol, name, expected, expectedErr, have, haveErr)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package gjsonquery
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Kinds of errors reported by DoesMatch, Query.Match and Validate.
+// Use errors.Is to check the kind and errors.As with *QueryError to get the details.
+var (
+	// ErrInvalidQuery matches every error caused by malformed query (all kinds except ErrTypeMismatch, ErrNaN and ErrCyclicValue).
+	ErrInvalidQuery = errors.New("invalid query")
+
+	// ErrUnknownQueryType is reported when matcher argument is neither a map nor a list.
+	ErrUnknownQueryType = errors.New("unknown query type")
+	// ErrNotAMap is reported when column expectation is neither a scalar, a list nor a map of comparators.
+	ErrNotAMap = errors.New("not a map")
+	// ErrUnknownComparator is reported for comparator names which are not supported.
+	ErrUnknownComparator = errors.New("unknown comparator")
+	// ErrUnknownExpectedType is reported when expectation type is not supported by the comparator (or, by MarshalJSON, by JSON).
+	ErrUnknownExpectedType = errors.New("unknown expected type")
+	// ErrInvalidPath is reported for column names which are not valid in the configured path syntax.
+	ErrInvalidPath = errors.New("invalid path")
+	// ErrInvalidPattern is reported for regular expressions which do not compile or have unknown flags.
+	ErrInvalidPattern = errors.New("invalid pattern")
+
+	// ErrTypeMismatch is reported when value in data can not be compared with the expectation.
+	ErrTypeMismatch = errors.New("type mismatch")
+	// ErrNaN is reported when value in data is NaN and has to be ordered ("$gt", "$gte", "$lt", "$lte").
+	ErrNaN = errors.New("NaN can not be ordered")
+	// ErrCyclicValue is reported when Go value in data references itself and has to be compared as a whole.
+	ErrCyclicValue = errors.New("cyclic value")
+)
+
+// QueryError describes failure of query evaluation.
+type QueryError struct {
+	// Kind is one of the Err* sentinel errors.
+	Kind error
+	// Op is the name of the step which detected the problem, e.g. "matchValue".
+	Op string
+	// Column is the column being matched, empty when failure is not related to a column.
+	Column string
+	// Comparator is the comparator name as used in query, e.g. "!$in".
+	Comparator string
+	// Value is the offending value: part of the query or, for ErrTypeMismatch, ErrNaN and ErrCyclicValue, value in data.
+	Value interface{}
+}
+
+func newQueryError(op string, kind error, value interface{}) *QueryError {
+	return &QueryError{Kind: kind, Op: op, Value: value}
+}
+
+func (e *QueryError) Error() string {
+	var details []string
+	if e.Column != "" {
+		details = append(details, fmt.Sprintf("column: %q", e.Column))
+	}
+	if e.Comparator != "" {
+		details = append(details, fmt.Sprintf("comparator: %q", e.Comparator))
+	}
+	if e.Value != nil || e.Kind == ErrTypeMismatch {
+		details = append(details, fmt.Sprintf("type: %T", e.Value))
+	}
+
+	msg := e.Op + ": " + e.Kind.Error()
+	if len(details) > 0 {
+		msg += " (" + strings.Join(details, ", ") + ")"
+	}
+	return msg
+}
+
+func (e *QueryError) Unwrap() error {
+	return e.Kind
+}
+
+// Is allows matching any query related kind with ErrInvalidQuery.
+func (e *QueryError) Is(target error) bool {
+	return target == ErrInvalidQuery && e.Kind != ErrTypeMismatch && e.Kind != ErrNaN && e.Kind != ErrCyclicValue
+}
+
+// errorWithColumn attaches column to the QueryError if not set yet.
+func errorWithColumn(err error, column string) error {
+	if qErr, ok := err.(*QueryError); ok && qErr.Column == "" {
+		qErr.Column = column
+	}
+	return err
+}
+
+// errorWithComparator attaches comparator name to the QueryError if not set yet.
+func errorWithComparator(err error, name string) error {
+	if qErr, ok := err.(*QueryError); ok && qErr.Comparator == "" {
+		qErr.Comparator = name
+	}
+	return err
+}
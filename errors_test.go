@@ -0,0 +1,176 @@
+package gjsonquery_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	. "github.com/szpakas/gjsonquery"
+)
+
+func TestQueryError(t *testing.T) {
+	type testCase struct {
+		symbol  string
+		query   interface{}
+		data    map[string]interface{}
+		kind    error
+		invalid bool
+		message string
+		column  string
+		cmp     string
+	}
+
+	var tests = []testCase{
+		{
+			"AA", map[string]interface{}{"$and": 101}, map[string]interface{}{},
+			ErrUnknownQueryType, true, `matcherAnd: unknown query type (type: int)`, "", "",
+		},
+		{
+			"AB", map[string]interface{}{"$or": "a"}, map[string]interface{}{},
+			ErrUnknownQueryType, true, `matcherOr: unknown query type (type: string)`, "", "",
+		},
+		{
+			"AC", map[string]interface{}{"a": map[int]interface{}{1: 1}}, map[string]interface{}{},
+			ErrNotAMap, true, `matchValue: not a map (column: "a", type: map[int]interface {})`, "a", "",
+		},
+		{
+			"AD", map[string]interface{}{"a": map[string]interface{}{"!$bogus": 1}}, map[string]interface{}{},
+			ErrUnknownComparator, true, `matchComparator: unknown comparator (column: "a", comparator: "!$bogus")`, "a", "!$bogus",
+		},
+		{
+			"AE", map[string]interface{}{"$bogus": 1}, map[string]interface{}{},
+			ErrUnknownComparator, true, `matchComparator: unknown comparator (comparator: "$bogus")`, "", "$bogus",
+		},
+		{
+			"AF", map[string]interface{}{"a": map[string]interface{}{"$in": "x"}}, map[string]interface{}{},
+			ErrUnknownExpectedType, true, `comparatorIn: unknown expected type (column: "a", comparator: "$in", type: string)`, "a", "$in",
+		},
+		{
+			"AG", map[string]interface{}{"a": map[string]interface{}{"$gt": true}}, map[string]interface{}{"a": 1},
+			ErrUnknownExpectedType, true, `comparator: unknown expected type (column: "a", comparator: "$gt", type: bool)`, "a", "$gt",
+		},
+		{
+			"AH", map[string]interface{}{"a": map[string]interface{}{"$lt": math.NaN()}}, map[string]interface{}{"a": 1},
+			ErrUnknownExpectedType, true, `comparator: unknown expected type (column: "a", comparator: "$lt", type: float64)`, "a", "$lt",
+		},
+		// data related
+		{
+			"BA", map[string]interface{}{"a.b": map[string]interface{}{"$gt": 1}}, map[string]interface{}{"a": map[string]interface{}{"b": "x"}},
+			ErrTypeMismatch, false, `comparator: type mismatch (column: "a.b", comparator: "$gt", type: string)`, "a.b", "$gt",
+		},
+		{
+			"BB", map[string]interface{}{"a": map[string]interface{}{"$lte": 1.5}}, map[string]interface{}{},
+			ErrTypeMismatch, false, `comparator: type mismatch (column: "a", comparator: "$lte", type: <nil>)`, "a", "$lte",
+		},
+		{
+			"BC", map[string]interface{}{"a": map[string]interface{}{"$contains": "x"}}, map[string]interface{}{"a": 1},
+			ErrTypeMismatch, false, `comparatorContains: type mismatch (column: "a", comparator: "$contains", type: int)`, "a", "$contains",
+		},
+		{
+			"BD", map[string]interface{}{"a": map[string]interface{}{"$gt": 1}}, map[string]interface{}{"a": math.NaN()},
+			ErrNaN, false, `comparator: NaN can not be ordered (column: "a", comparator: "$gt", type: float64)`, "a", "$gt",
+		},
+	}
+
+	for _, tc := range tests {
+		_, err := DoesMatch(tc.query, tc.data)
+
+		if !errors.Is(err, tc.kind) {
+			t.Errorf("[%s] Mismatch on kind => expected: %v, have: %v", tc.symbol, tc.kind, err)
+		}
+		if errors.Is(err, ErrInvalidQuery) != tc.invalid {
+			t.Errorf("[%s] Mismatch on ErrInvalidQuery => expected: %t, have: %v", tc.symbol, tc.invalid, err)
+		}
+
+		var qErr *QueryError
+		if !errors.As(err, &qErr) {
+			t.Errorf("[%s] Expected *QueryError, have: %#+v", tc.symbol, err)
+			continue
+		}
+		if qErr.Error() != tc.message {
+			t.Errorf("[%s] Mismatch on message\nexpected => %s\n    have => %s", tc.symbol, tc.message, qErr.Error())
+		}
+		if qErr.Column != tc.column || qErr.Comparator != tc.cmp {
+			t.Errorf("[%s] Mismatch on details => expected: %q/%q, have: %q/%q", tc.symbol, tc.column, tc.cmp, qErr.Column, qErr.Comparator)
+		}
+
+		// compiled query has to report the same details
+		q, err := Compile(tc.query)
+		if err == nil {
+			_, err = q.Match(tc.data)
+		}
+		var qErrCompiled *QueryError
+		if !errors.As(err, &qErrCompiled) || qErrCompiled.Error() != tc.message {
+			t.Errorf("[%s] Mismatch on compiled query error\nexpected => %s\n    have => %v", tc.symbol, tc.message, err)
+		}
+	}
+}
+
+func TestEvaluationOrder(t *testing.T) {
+	// keys are evaluated in sort order, evaluation stops on the first decisive result or error
+	var tests = []struct {
+		symbol   string
+		query    map[string]interface{}
+		data     map[string]interface{}
+		expected bool
+		column   string
+		cmp      string
+	}{
+		{
+			"AA", map[string]interface{}{"a": 1, "b": map[string]interface{}{"$gt": 1}}, map[string]interface{}{"a": 2, "b": "x"},
+			false, "", "",
+		},
+		{
+			"AB", map[string]interface{}{"a": map[string]interface{}{"$gt": 1}, "b": 1}, map[string]interface{}{"a": "x", "b": 2},
+			false, "a", "$gt",
+		},
+		{
+			"AC", map[string]interface{}{"b": map[string]interface{}{"$gt": 1}, "a": map[string]interface{}{"$lt": 1}}, map[string]interface{}{"a": "x", "b": "x"},
+			false, "a", "$lt",
+		},
+		{
+			"AD", map[string]interface{}{"a": map[string]interface{}{"$lt": 5, "$gt": 1}}, map[string]interface{}{"a": "x"},
+			false, "a", "$gt",
+		},
+		{
+			"AE", map[string]interface{}{"a": map[string]interface{}{"$lt": 5, "$startsWith": "x"}}, map[string]interface{}{"a": 7},
+			false, "", "",
+		},
+		// -- $or stops on the first match
+		{
+			"BA", map[string]interface{}{"$or": map[string]interface{}{"a": 1, "b": map[string]interface{}{"$gt": 1}}}, map[string]interface{}{"a": 1, "b": "x"},
+			true, "", "",
+		},
+		{
+			"BB", map[string]interface{}{"$or": map[string]interface{}{"a": map[string]interface{}{"$gt": 1}, "b": 1}}, map[string]interface{}{"a": "x", "b": 1},
+			false, "a", "$gt",
+		},
+	}
+
+	for _, tc := range tests {
+		q, err := Compile(tc.query)
+		if err != nil {
+			t.Fatalf("[%s] unexpected compile error: %v", tc.symbol, err)
+		}
+		// map iteration order is random, repeat to make sure result does not depend on it
+		for i := 0; i < 20; i++ {
+			for _, match := range []func() (bool, error){
+				func() (bool, error) { return DoesMatch(tc.query, tc.data) },
+				func() (bool, error) { return q.Match(tc.data) },
+			} {
+				matched, err := match()
+				if matched != tc.expected {
+					t.Fatalf("[%s] Mismatch on result => expected: %t, have: %t", tc.symbol, tc.expected, matched)
+				}
+				var qErr *QueryError
+				if tc.column == "" {
+					if err != nil {
+						t.Fatalf("[%s] Unexpected error: %v", tc.symbol, err)
+					}
+				} else if !errors.As(err, &qErr) || qErr.Column != tc.column || qErr.Comparator != tc.cmp {
+					t.Fatalf("[%s] Mismatch on error => expected: %q/%q, have: %v", tc.symbol, tc.column, tc.cmp, err)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package gjsonquery
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Types of Explanation nodes.
+const (
+	EXPLAIN_AND        = "$and"
+	EXPLAIN_OR         = "$or"
+	EXPLAIN_NOT        = "$not"
+	EXPLAIN_COLUMN     = "column"
+	EXPLAIN_COMPARATOR = "comparator"
+)
+
+// Explanation is a tree mirroring the query with results of its evaluation against particular data.
+//
+// Evaluation stops as soon as result is known, exactly as in DoesMatch.
+// Nodes which were not evaluated are present in the tree with Skipped set.
+type Explanation struct {
+	// Type is one of EXPLAIN_* node types.
+	Type string `json:"type"`
+	// Column is set for EXPLAIN_COLUMN nodes.
+	Column string `json:"column,omitempty"`
+	// Value is the value fetched from data for EXPLAIN_COLUMN nodes, list of values for wildcard paths.
+	Value interface{} `json:"value,omitempty"`
+	// Found tells if Value exists in data.
+	Found bool `json:"found,omitempty"`
+	// Comparator is the comparator name as used in query, set for EXPLAIN_COMPARATOR nodes.
+	Comparator string `json:"comparator,omitempty"`
+	// Expected is the expectation of the comparator.
+	Expected interface{} `json:"expected,omitempty"`
+	// Result of the node, comparators are reported with negation applied.
+	Result bool `json:"result"`
+	// Skipped is set when node was not evaluated because result was already known.
+	Skipped bool `json:"skipped,omitempty"`
+	// Children are $and/$or/$not operands or comparators of the column.
+	Children []*Explanation `json:"children,omitempty"`
+}
+
+// Explain evaluates query against data as DoesMatch does and reports how the result was reached.
+// Whole query is validated first, malformed query is reported with *ValidationError.
+func Explain(query interface{}, data map[string]interface{}) (*Explanation, error) {
+	return defaultMatcher.Explain(query, data)
+}
+
+// Explain evaluates query against data using Matcher configuration, see Explain.
+func (m *Matcher) Explain(query interface{}, data map[string]interface{}) (*Explanation, error) {
+	q, err := m.Compile(query)
+	if err != nil {
+		return nil, err
+	}
+	return q.Explain(data)
+}
+
+// Explain evaluates compiled query against data and reports how the result was reached.
+func (q *Query) Explain(data map[string]interface{}) (*Explanation, error) {
+	return q.root.explain(q.opts, data, false)
+}
+
+// String renders explanation as an indented text tree, one node per line.
+func (e *Explanation) String() string {
+	var b strings.Builder
+	e.write(&b, 0)
+	return b.String()
+}
+
+func (e *Explanation) write(b *strings.Builder, depth int) {
+	b.WriteString(strings.Repeat("  ", depth))
+	switch e.Type {
+	case EXPLAIN_COLUMN:
+		b.WriteString(e.Column)
+		if !e.Skipped {
+			if e.Found {
+				b.WriteString(" = " + formatValue(e.Value))
+			} else {
+				b.WriteString(" (missing)")
+			}
+		}
+	case EXPLAIN_COMPARATOR:
+		b.WriteString(e.Comparator + " " + formatValue(e.Expected))
+	default:
+		b.WriteString(e.Type)
+	}
+	if e.Skipped {
+		b.WriteString(" => skipped\n")
+	} else {
+		fmt.Fprintf(b, " => %t\n", e.Result)
+	}
+	for _, child := range e.Children {
+		child.write(b, depth+1)
+	}
+}
+
+// formatValue renders value as JSON when possible.
+func formatValue(v interface{}) string {
+	if out, err := json.Marshal(v); err == nil {
+		return string(out)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// explainChildren evaluates children until result is known (stopOn), remaining children are reported as skipped.
+func explainChildren(o *options, data interface{}, children []node, stopOn bool, skip bool) ([]*Explanation, bool, error) {
+	out := make([]*Explanation, 0, len(children))
+	decided := false
+	for _, child := range children {
+		e, err := child.explain(o, data, skip || decided)
+		if err != nil {
+			return nil, false, err
+		}
+		out = append(out, e)
+		if !e.Skipped && e.Result == stopOn {
+			decided = true
+		}
+	}
+	return out, decided, nil
+}
+
+func (n *andNode) explain(o *options, data interface{}, skip bool) (*Explanation, error) {
+	children, decided, err := explainChildren(o, data, n.children, false, skip)
+	if err != nil {
+		return nil, err
+	}
+	return &Explanation{Type: EXPLAIN_AND, Result: !decided && !skip, Skipped: skip, Children: children}, nil
+}
+
+func (n *orNode) explain(o *options, data interface{}, skip bool) (*Explanation, error) {
+	children, decided, err := explainChildren(o, data, n.children, true, skip)
+	if err != nil {
+		return nil, err
+	}
+	return &Explanation{Type: EXPLAIN_OR, Result: decided, Skipped: skip, Children: children}, nil
+}
+
+func (n *notNode) explain(o *options, data interface{}, skip bool) (*Explanation, error) {
+	child, err := n.child.explain(o, data, skip)
+	if err != nil {
+		return nil, err
+	}
+	return &Explanation{Type: EXPLAIN_NOT, Result: !skip && !child.Result, Skipped: skip, Children: []*Explanation{child}}, nil
+}
+
+func (n *columnNode) explain(o *options, data interface{}, skip bool) (*Explanation, error) {
+	e := &Explanation{Type: EXPLAIN_COLUMN, Column: n.column, Skipped: skip}
+	var valueInData interface{}
+	if !skip {
+		valueInData, e.Found = fetchPath(o, data, n.path)
+		e.Value = displayValue(valueInData)
+	}
+	candidates, _ := valueInData.([]interface{})
+
+	decided := skip
+	for i := range n.checks {
+		c := &n.checks[i]
+		ce := &Explanation{Type: EXPLAIN_COMPARATOR, Comparator: c.name, Expected: c.expectation, Skipped: decided}
+		if !decided {
+			var err error
+			if n.multi {
+				ce.Result, err = c.matchAny(o, candidates)
+			} else {
+				ce.Result, err = c.match(o, valueInData, e.Found)
+			}
+			if err != nil {
+				return nil, errorWithColumn(err, n.column)
+			}
+			// first mismatch determine result
+			decided = !ce.Result
+		}
+		e.Children = append(e.Children, ce)
+	}
+	e.Result = !decided
+	return e, nil
+}
+
+func (n *comparatorNode) explain(o *options, data interface{}, skip bool) (*Explanation, error) {
+	e := &Explanation{Type: EXPLAIN_COMPARATOR, Comparator: n.check.name, Expected: n.check.expectation, Skipped: skip}
+	if !skip {
+		var err error
+		if e.Result, err = n.match(o, data); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
@@ -0,0 +1,135 @@
+package gjsonquery_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	. "github.com/szpakas/gjsonquery"
+)
+
+func TestExplainResult(t *testing.T) {
+	for _, tDef := range doesMatchTests {
+		for _, tCase := range tDef.tests {
+			e, err := Explain(tDef.query, tCase.data)
+
+			if !sameError(tCase.err, err) {
+				t.Errorf("[%s|%s] Mismatch on error => expected: %#+v, have: %#+v", tDef.symbol, tCase.symbol, tCase.err, err)
+			}
+			if err != nil {
+				if e != nil {
+					t.Errorf("[%s|%s] Explanation should be returned only on success, have: %#+v", tDef.symbol, tCase.symbol, e)
+				}
+				continue
+			}
+
+			if e.Result != tCase.expected {
+				t.Errorf("[%s|%s] Mismatch => expected: %#+v, have: %#+v", tDef.symbol, tCase.symbol, tCase.expected, e.Result)
+			}
+		}
+	}
+}
+
+// lists keep order of operands which makes explanation deterministic
+var explainQuery = []interface{}{
+	map[string]interface{}{"age": map[string]interface{}{"$gte": 18}},
+	map[string]interface{}{"$or": []interface{}{
+		map[string]interface{}{"role": "admin"},
+		map[string]interface{}{"!tags.*": []interface{}{"banned"}},
+	}},
+	map[string]interface{}{"name": map[string]interface{}{"$exists": true}},
+}
+
+func TestExplain(t *testing.T) {
+	e, err := Explain(explainQuery, map[string]interface{}{"age": 21, "role": "user", "tags": []interface{}{"new", "banned"}})
+	if err != nil {
+		t.Fatalf("Explain failed: %s", err)
+	}
+
+	expected := &Explanation{Type: EXPLAIN_AND, Result: false, Children: []*Explanation{
+		{Type: EXPLAIN_AND, Result: true, Children: []*Explanation{
+			{Type: EXPLAIN_COLUMN, Column: "age", Value: 21, Found: true, Result: true, Children: []*Explanation{
+				{Type: EXPLAIN_COMPARATOR, Comparator: "$gte", Expected: 18, Result: true},
+			}},
+		}},
+		{Type: EXPLAIN_AND, Result: false, Children: []*Explanation{
+			{Type: EXPLAIN_OR, Result: false, Children: []*Explanation{
+				{Type: EXPLAIN_AND, Result: false, Children: []*Explanation{
+					{Type: EXPLAIN_COLUMN, Column: "role", Value: "user", Found: true, Result: false, Children: []*Explanation{
+						{Type: EXPLAIN_COMPARATOR, Comparator: "$is", Expected: "admin", Result: false},
+					}},
+				}},
+				{Type: EXPLAIN_AND, Result: false, Children: []*Explanation{
+					{Type: EXPLAIN_NOT, Result: false, Children: []*Explanation{
+						{Type: EXPLAIN_COLUMN, Column: "tags.*", Value: []interface{}{"new", "banned"}, Found: true, Result: true, Children: []*Explanation{
+							{Type: EXPLAIN_COMPARATOR, Comparator: "$in", Expected: []interface{}{"banned"}, Result: true},
+						}},
+					}},
+				}},
+			}},
+		}},
+		{Type: EXPLAIN_AND, Skipped: true, Children: []*Explanation{
+			{Type: EXPLAIN_COLUMN, Column: "name", Skipped: true, Children: []*Explanation{
+				{Type: EXPLAIN_COMPARATOR, Comparator: "$exists", Expected: true, Skipped: true},
+			}},
+		}},
+	}}
+
+	if !reflect.DeepEqual(e, expected) {
+		have, _ := json.MarshalIndent(e, "", "  ")
+		t.Errorf("Mismatch on explanation, have:\n%s", have)
+	}
+}
+
+func TestExplanationString(t *testing.T) {
+	e, err := Explain(explainQuery, map[string]interface{}{"age": 16})
+	if err != nil {
+		t.Fatalf("Explain failed: %s", err)
+	}
+
+	expected := `$and => false
+  $and => false
+    age = 16 => false
+      $gte 18 => false
+  $and => skipped
+    $or => skipped
+      $and => skipped
+        role => skipped
+          $is "admin" => skipped
+      $and => skipped
+        $not => skipped
+          tags.* => skipped
+            $in ["banned"] => skipped
+  $and => skipped
+    name => skipped
+      $exists true => skipped
+`
+	if have := e.String(); have != expected {
+		t.Errorf("Mismatch on text\nexpected:\n%s\nhave:\n%s", expected, have)
+	}
+}
+
+func TestExplanationJSON(t *testing.T) {
+	e, err := Explain(map[string]interface{}{"a": map[string]interface{}{"!$in": []interface{}{1, 2}}}, map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("Explain failed: %s", err)
+	}
+
+	have, err := json.Marshal(e)
+	if err != nil {
+		t.Fatalf("Marshal failed: %s", err)
+	}
+	expected := `{"type":"$and","result":true,"children":[` +
+		`{"type":"column","column":"a","result":true,"children":[` +
+		`{"type":"comparator","comparator":"!$in","expected":[1,2],"result":true}]}]}`
+	if string(have) != expected {
+		t.Errorf("Mismatch on JSON\nexpected => %s\n    have => %s", expected, have)
+	}
+}
+
+func TestExplainInvalidQuery(t *testing.T) {
+	_, err := Explain(map[string]interface{}{"a": map[string]interface{}{"$bogus": 1}}, map[string]interface{}{"a": 1})
+	if !sameError(ErrUnknownComparator, err) {
+		t.Errorf("Mismatch on error => expected: %#+v, have: %#+v", ErrUnknownComparator, err)
+	}
+}
@@ -5,3 +5,21 @@ var FetchValue = fetchValue
 
 // from comparators
 var ComparatorGeneric = comparatorGeneric
+
+var CompileRegex = compileRegex
+
+func RegexCacheLen() int {
+	regexCache.RLock()
+	defer regexCache.RUnlock()
+	return len(regexCache.patterns)
+}
+
+type ComparatorType = comparatorType
+
+// from numbers
+var Equal = equal
+
+// from json
+func DecodeSelected(query interface{}, data []byte) (map[string]interface{}, error) {
+	return decodeSelected(defaultOptions, newSelector(defaultOptions, query), data)
+}
@@ -1,26 +1,32 @@
 package gjsonquery
 
 import (
-	"errors"
+	"encoding/json"
+	"math/big"
 	"strings"
 )
 
+// COLUMN_LEVEL_SEPARATOR is the default separator of column path elements, see WithSeparator.
 const COLUMN_LEVEL_SEPARATOR string = "."
 
+// DoesMatch reports whether data matches the query using default configuration.
+// Numbers are compared by value regardless of their Go type and column paths use "." as a separator.
+// Use NewMatcher for other configurations.
 func DoesMatch(query interface{}, data map[string]interface{}) (bool, error) {
-	// first level match is always AND
-	return matcherAnd(query, data)
+	return defaultMatcher.DoesMatch(query, data)
 }
 
 // -- matchers
 
-func matcherAnd(query interface{}, data map[string]interface{}) (bool, error) {
+// matcherAnd evaluates map entries in sorted key order (list items in list order) and stops on the first
+// mismatch or error, so later entries are not evaluated and their errors are not reported.
+func matcherAnd(o *options, query interface{}, data interface{}) (bool, error) {
 
 	switch v := interface{}(query).(type) {
 	// -- query is a key->value map
 	case map[string]interface{}:
-		for column, expectedValue := range v {
-			matched, err := matchValue(column, expectedValue, data)
+		for _, column := range sortedKeys(v) {
+			matched, err := matchValue(o, column, v[column], data)
 			if err != nil {
 				return false, err
 			}
@@ -35,7 +41,7 @@ func matcherAnd(query interface{}, data map[string]interface{}) (bool, error) {
 	case []interface{}:
 		for _, expectedValue := range v {
 			// list match is always and
-			matched, err := matcherAnd(expectedValue, data)
+			matched, err := matcherAnd(o, expectedValue, data)
 			if err != nil {
 				return false, err
 			}
@@ -46,19 +52,23 @@ func matcherAnd(query interface{}, data map[string]interface{}) (bool, error) {
 		}
 		// defaults to match if nothing failed first
 		return true, nil
+	// -- query built with Field, And, Or and Not
+	case Expr:
+		return matcherAnd(o, v.Query(), data)
 	}
 
 	// unknown type
-	return false, errors.New("matcherAnd: unknown query type")
+	return false, newQueryError("matcherAnd", ErrUnknownQueryType, query)
 }
 
-func matcherOr(query interface{}, data map[string]interface{}) (bool, error) {
+// matcherOr evaluates entries in the same order as matcherAnd and stops on the first match or error.
+func matcherOr(o *options, query interface{}, data interface{}) (bool, error) {
 
 	switch v := interface{}(query).(type) {
 	// -- query is a key->value map
 	case map[string]interface{}:
-		for column, expectedValue := range v {
-			matched, err := matchValue(column, expectedValue, data)
+		for _, column := range sortedKeys(v) {
+			matched, err := matchValue(o, column, v[column], data)
 			if err != nil {
 				return false, err
 			}
@@ -73,7 +83,7 @@ func matcherOr(query interface{}, data map[string]interface{}) (bool, error) {
 	case []interface{}:
 		for _, expectedValue := range v {
 			// list match is always and
-			matched, err := matcherAnd(expectedValue, data)
+			matched, err := matcherAnd(o, expectedValue, data)
 			if err != nil {
 				return false, err
 			}
@@ -84,10 +94,13 @@ func matcherOr(query interface{}, data map[string]interface{}) (bool, error) {
 		}
 		// defaults to NO match if nothing matched first
 		return false, nil
+	// -- query built with Field, And, Or and Not
+	case Expr:
+		return matcherOr(o, v.Query(), data)
 	}
 
 	// unknown type
-	return false, errors.New("matcherAnd: unknown query type")
+	return false, newQueryError("matcherOr", ErrUnknownQueryType, query)
 }
 
 // -- logic/helpers
@@ -102,21 +115,57 @@ const (
 	COMPARATOR_GTE
 	COMPARATOR_LT
 	COMPARATOR_LTE
+	COMPARATOR_CONTAINS
+	COMPARATOR_EXISTS
+	COMPARATOR_REGEX
+	COMPARATOR_STARTS_WITH
+	COMPARATOR_ENDS_WITH
+	COMPARATOR_IIS
 )
 
+var comparatorNames = map[comparatorType]string{
+	COMPARATOR_NOT:         "$not",
+	COMPARATOR_IS:          "$is",
+	COMPARATOR_IN:          "$in",
+	COMPARATOR_GT:          "$gt",
+	COMPARATOR_GTE:         "$gte",
+	COMPARATOR_LT:          "$lt",
+	COMPARATOR_LTE:         "$lte",
+	COMPARATOR_CONTAINS:    "$contains",
+	COMPARATOR_EXISTS:      "$exists",
+	COMPARATOR_REGEX:       "$regex",
+	COMPARATOR_STARTS_WITH: "$startsWith",
+	COMPARATOR_ENDS_WITH:   "$endsWith",
+	COMPARATOR_IIS:         "$iis",
+}
+
 type comparator struct {
 	cType   comparatorType
 	negated bool
 }
 
-func matchValue(column string, expectation interface{}, data map[string]interface{}) (bool, error) {
+// String returns comparator name as used in query, e.g. "!$in".
+func (c comparator) String() string {
+	name, ok := comparatorNames[c.cType]
+	if !ok {
+		name = "$unknown"
+	}
+	if c.negated {
+		return "!" + name
+	}
+	return name
+}
 
-	_d("[matchValue]\n\tcolumn: %#v\n\texpectation: %#v\n\tdata: %#v\n", column, expectation, data)
+func matchValue(o *options, column string, expectation interface{}, data interface{}) (matched bool, err error) {
+	if o.tracer != nil {
+		defer func() {
+			o.trace(TraceEvent{Op: TRACE_MATCH_VALUE, Column: column, Expectation: expectation, Result: matched, Err: err})
+		}()
+	}
 
 	// -- direct detection based on column
-	if string(column[0]) == "!" {
-		_d("[matchValue] DIRECT: !\n")
-		matched, err := matchValue(column[1:], expectation, data)
+	if strings.HasPrefix(column, "!") {
+		matched, err := matchValue(o, column[1:], expectation, data)
 		if err != nil {
 			return false, err
 		}
@@ -125,14 +174,11 @@ func matchValue(column string, expectation interface{}, data map[string]interfac
 
 	switch column {
 	case "$and":
-		_d("[matchValue] DIRECT: matcherAnd\n")
-		return matcherAnd(expectation, data)
+		return matcherAnd(o, expectation, data)
 	case "$or":
-		_d("[matchValue] DIRECT: matcherOr\n")
-		return matcherOr(expectation, data)
+		return matcherOr(o, expectation, data)
 	case "$not":
-		_d("[matchValue] DIRECT: matcherNotAnd\n")
-		matched, err := matcherAnd(expectation, data)
+		matched, err := matcherAnd(o, expectation, data)
 		if err != nil {
 			return false, err
 		}
@@ -140,58 +186,105 @@ func matchValue(column string, expectation interface{}, data map[string]interfac
 	}
 
 	// direct comparator which is not matcher
-	if string(column[0]) == "$" {
-		_d("[matchValue] DIRECT: triggerComparator\n")
-		comparator := detectComparator(column)
-		return matchComparator(comparator, data, expectation)
+	if strings.HasPrefix(column, "$") {
+		comparator := detectComparator(o, column)
+		matched, err := matchComparator(o, comparator, wholeData(data), true, expectation)
+		return matched, errorWithComparator(err, column)
 	}
 
 	// -- if still undetermined fall-back to expectation type based detection
-	var cmp comparator
+	var (
+		cmps         []comparator
+		names        []string
+		expectations []interface{}
+	)
 
 	switch interface{}(expectation).(type) {
-	// -- expectation is logical scalar (not list/slice, nor map)
-	// TODO: switch to less naive solution
-	case string, int, float32, float64:
-		cmp = comparator{cType: COMPARATOR_IS, negated: false}
 	// -- expectation is a list -> wrap in "$in" comparator
 	case []interface{}:
-		cmp = comparator{cType: COMPARATOR_IN, negated: false}
+		cmps = []comparator{{cType: COMPARATOR_IN, negated: false}}
+		names = []string{"$in"}
+		expectations = []interface{}{expectation}
+	default:
+		// -- expectation is logical scalar (not list/slice, nor map), null included
+		if isScalar(expectation) {
+			cmps = []comparator{{cType: COMPARATOR_IS, negated: false}}
+			names = []string{"$is"}
+			expectations = []interface{}{expectation}
+		}
 	}
 
-	// -- still undetermined -> pull comparator/expectation from expectation
-	if cmp.cType == 0 {
+	// -- still undetermined -> pull comparators/expectations from expectation
+	if cmps == nil {
 		expectationAsMap, ok := expectation.(map[string]interface{})
 		if !ok {
-			_d("[matchValue] ERROR: NOT_A_MAP\n")
-			return false, errors.New("matchValue: not a map")
+			return false, errorWithColumn(newQueryError("matchValue", ErrNotAMap, expectation), column)
 		}
 
-		if len(expectationAsMap) > 1 {
-			// TODO: implement properly
-			_d("[matchValue] ERROR: MULTIPLE_EXPECTATIONS\n")
-			return false, errors.New("matchValue: multiple expectations")
-		}
-		for expKey, expValue := range expectationAsMap {
-			cmp = detectComparator(expKey)
-			expectation = expValue
-			_d("[matchValue] unpacking comparator\n\tcomparator name: %#v,\n\tcomparator type: %#v,\n\texpectation: %#v\n", expKey, cmp, expectation)
-			// we only allow one iteration (check on length should ensure this either way)
-			break
+		// multiple comparators on one level are joined with implicit AND, evaluated in sorted order
+		for _, expKey := range sortedKeys(expectationAsMap) {
+			cmp := detectComparator(o, expKey)
+			cmps = append(cmps, cmp)
+			names = append(names, expKey)
+			expectations = append(expectations, expectationAsMap[expKey])
 		}
 	}
 
 	// -- obtain value
-	valueInData, existsInData := fetchValue(data, column)
-	_d("[matchValue]\n\tvalueInData: %#v\n\texistsInData: %#v\n", valueInData, existsInData)
-	_d("[matchValue] comparator: %#v\n", cmp)
+	path, err := splitColumn(o, column)
+	if err != nil {
+		return false, errorWithColumn(err, column)
+	}
+	valueInData, existsInData := fetchPath(o, data, path)
+	if o.tracer != nil {
+		o.trace(TraceEvent{Op: TRACE_FETCH_VALUE, Column: column, Value: displayValue(valueInData), Found: existsInData})
+	}
+	multi := hasWildcard(path)
+
+	// -- perform comparison(s)
+	for i, cmp := range cmps {
+		var (
+			matched bool
+			err     error
+		)
+		if multi {
+			candidates, _ := valueInData.([]interface{})
+			matched, err = matchAny(o, cmp, candidates, expectations[i])
+		} else {
+			matched, err = matchComparator(o, cmp, valueInData, existsInData, expectations[i])
+		}
+		if err != nil {
+			return false, errorWithComparator(errorWithColumn(err, column), names[i])
+		}
+		if !matched {
+			// first mismatch determine result -> no match
+			return false, nil
+		}
+	}
+	// defaults to match if nothing failed first
+	return true, nil
+}
 
-	// -- perform comparison
-	return matchComparator(cmp, valueInData, expectation)
+// isScalar reports whether v is a simple value (string, number, boolean or null).
+// Scalars are safe to use as map keys.
+func isScalar(v interface{}) bool {
+	switch v.(type) {
+	case nil, bool, string, json.Number, *big.Int,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64,
+		float32, float64:
+		return true
+	}
+	return false
 }
 
-func detectComparator(comparatorName string) (cmp comparator) {
-	_d("[detectComparator] comparatorName: %#v\n", comparatorName)
+func detectComparator(o *options, comparatorName string) (cmp comparator) {
+	if o.tracer != nil {
+		defer func() {
+			o.trace(TraceEvent{Op: TRACE_DETECT_COMPARATOR, Comparator: comparatorName, Result: cmp.cType != 0})
+		}()
+	}
+
 	// -- detect negation directly in comparator via "!"
 	negate := false
 	var comparatorNameFiltered string = comparatorName
@@ -199,7 +292,6 @@ func detectComparator(comparatorName string) (cmp comparator) {
 		if string(chr) == "!" {
 			negate = !negate
 			comparatorNameFiltered = comparatorName[i+1:]
-			_d("[detectComparator] negation => negate: %t, comparatorNameFiltered: %#v\n", negate, comparatorNameFiltered)
 		}
 	}
 
@@ -218,30 +310,60 @@ func detectComparator(comparatorName string) (cmp comparator) {
 		cmp = comparator{cType: COMPARATOR_LT, negated: negate}
 	case "$lte":
 		cmp = comparator{cType: COMPARATOR_LTE, negated: negate}
+	case "$contains":
+		cmp = comparator{cType: COMPARATOR_CONTAINS, negated: negate}
+	case "$exists":
+		cmp = comparator{cType: COMPARATOR_EXISTS, negated: negate}
+	case "$regex", "$matches":
+		cmp = comparator{cType: COMPARATOR_REGEX, negated: negate}
+	case "$startsWith":
+		cmp = comparator{cType: COMPARATOR_STARTS_WITH, negated: negate}
+	case "$endsWith":
+		cmp = comparator{cType: COMPARATOR_ENDS_WITH, negated: negate}
+	case "$iis":
+		cmp = comparator{cType: COMPARATOR_IIS, negated: negate}
 	}
 
-	_d("[detectComparator] RETURN: %#v\n", cmp)
 	return
 }
 
-func matchComparator(cmp comparator, valueInData, expectation interface{}) (out bool, err error) {
-	_d("[matchComparator]\n\tcomparator: %#v\n\tvalueInData: %#v\n\texpectation: %#v\n", cmp, valueInData, expectation)
+// matchComparator compares value in data with expectation, existsInData tells missing keys apart from nulls.
+func matchComparator(o *options, cmp comparator, valueInData interface{}, existsInData bool, expectation interface{}) (out bool, err error) {
+	if o.tracer != nil {
+		defer func() {
+			o.trace(TraceEvent{Op: TRACE_MATCH_COMPARATOR, Comparator: cmp.String(), Value: valueInData, Found: existsInData, Expectation: expectation, Result: out, Err: err})
+		}()
+	}
+
+	// Go values are converted only when compared, "$exists" does not need the value
+	if cmp.cType != COMPARATOR_EXISTS {
+		if valueInData, err = plainValue(valueInData); err != nil {
+			return false, err
+		}
+	}
 
 	var cmpResult bool
 
 	switch cmp.cType {
 	case COMPARATOR_IN:
-		cmpResult, err = comparatorIn(valueInData, expectation)
+		cmpResult, err = comparatorIn(valueInData, expectation, o.strictNumbers)
 	case COMPARATOR_IS:
-		cmpResult = comparatorIs(valueInData, expectation)
+		cmpResult = comparatorIs(valueInData, expectation, o.strictNumbers)
 	case COMPARATOR_NOT:
-		cmpResult, err = comparatorNot(valueInData, expectation)
+		cmpResult, err = comparatorNot(o, valueInData, expectation)
 	case COMPARATOR_GT, COMPARATOR_GTE, COMPARATOR_LT, COMPARATOR_LTE:
 		cmpResult, err = comparatorGeneric(cmp.cType, valueInData, expectation)
+	case COMPARATOR_CONTAINS:
+		cmpResult, err = comparatorContains(valueInData, expectation, o.strictNumbers)
+	case COMPARATOR_EXISTS:
+		cmpResult, err = comparatorExists(existsInData, expectation)
+	case COMPARATOR_REGEX:
+		cmpResult, err = comparatorRegex(valueInData, expectation)
+	case COMPARATOR_STARTS_WITH, COMPARATOR_ENDS_WITH, COMPARATOR_IIS:
+		cmpResult, err = comparatorString(cmp.cType, valueInData, expectation)
 	default:
 		// unknown comparator -> failure
-		_d("[matchComparator] ERROR: UNKNOWN_COMPARATOR\n")
-		cmpResult, err = false, errors.New("matchComparator: unknown comparator")
+		cmpResult, err = false, newQueryError("matchComparator", ErrUnknownComparator, nil)
 	}
 
 	if err != nil {
@@ -249,41 +371,44 @@ func matchComparator(cmp comparator, valueInData, expectation interface{}) (out
 	}
 
 	out = cmpResult != cmp.negated
-	_d("[matchComparator] RETURN: %t (negated: %t, cmpResult: %t)\n", out, cmp.negated, cmpResult)
 
 	return
 }
 
-func fetchValue(data map[string]interface{}, column string) (result interface{}, found bool) {
-	pathElements := strings.Split(column, COLUMN_LEVEL_SEPARATOR)
+// matchAny applies comparator to every candidate fetched with a wildcard path.
+// Positive form of the comparator has to match at least one candidate and negation is applied to the overall result,
+// e.g. {"items.*.sku": {"!$is": "x"}} matches when no item has sku "x".
+func matchAny(o *options, cmp comparator, candidates []interface{}, expectation interface{}) (bool, error) {
 
-	var isMap bool
-	_d("[fetchValue] enter\n\tpathElements: %#v\n\tdata: %#v\n\tcolumn: %#v\n", pathElements, data, column)
+	negated := cmp.negated
+	cmp.negated = false
 
-	for i, singlePathElement := range pathElements {
-		dataNext, existsInData := data[singlePathElement]
-
-		// case: no key on current level -> failure
-		if !existsInData {
-			_d("[fetchValue] RETURN: False (KEY_MISSING, iteration: %d)\n", i)
-			break
+	switch cmp.cType {
+	case COMPARATOR_EXISTS:
+		return matchComparator(o, comparator{cType: COMPARATOR_EXISTS, negated: negated}, nil, len(candidates) > 0, expectation)
+	case COMPARATOR_NOT:
+		// "$not" is a negated "$is"/"$in"
+		cmp.cType = COMPARATOR_IS
+		if _, ok := expectation.([]interface{}); ok {
+			cmp.cType = COMPARATOR_IN
 		}
+		negated = !negated
+	}
 
-		// case: last iteration -> pass the result
-		if i >= len(pathElements)-1 {
-			_d("[fetchValue] RETURN: %#v\n", dataNext)
-			result = dataNext
-			found = true
-			break
+	for _, candidate := range candidates {
+		matched, err := matchComparator(o, cmp, candidate, true, expectation)
+		if err != nil {
+			return false, err
 		}
-		// case: is NOT a last iteration -> remap data
-		data, isMap = dataNext.(map[string]interface{})
-
-		// case: no value yet, and next level is not a map -> failure
-		if !isMap {
-			_d("[fetchValue] RETURN: False (NOT_A_MAP, iteration: %d)\n", i)
-			break
+		if matched {
+			return !negated, nil
 		}
 	}
-	return
+	return negated, nil
+}
+
+func fetchValue(data map[string]interface{}, column string) (result interface{}, found bool) {
+	// default syntax never fails
+	path, _ := splitColumn(defaultOptions, column)
+	return fetchPath(defaultOptions, data, path)
 }
@@ -1,597 +1,1219 @@
 package gjsonquery_test
 
 import (
+	"encoding/json"
 	"errors"
 	"testing"
+	"time"
 
 	. "github.com/szpakas/gjsonquery"
 )
 
-func TestDoesMatch(t *testing.T) {
-	type subTestCase struct {
-		symbol   string
-		data     map[string]interface{}
-		expected bool
-		err      error
-	}
+type doesMatchSubTestCase struct {
+	symbol   string
+	data     map[string]interface{}
+	expected bool
+	err      error
+}
 
-	type testCase struct {
-		symbol string
-		query  interface{}
-		tests  []subTestCase
-		debug  bool
-	}
+type doesMatchTestCase struct {
+	symbol string
+	query  interface{}
+	tests  []doesMatchSubTestCase
+}
 
-	var tests = []testCase{
-		// match on single
-		{
-			symbol: "AA",
-			query:  map[string]interface{}{"a": 100},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 100}, true, nil},  // straight match
-				{"ab", map[string]interface{}{"a": 201}, false, nil}, // incorrect value
-				{"ac", map[string]interface{}{"b": 200}, false, nil}, // missing key, the same value on different key
-				{"ad", map[string]interface{}{}, false, nil},         // empty data
-				{"ae", map[string]interface{}{"a": 100, "b": 101, "c": 102}, true, nil},
-				{"af", map[string]interface{}{"a": 200, "b": 201, "c": 202}, false, nil},
-				// match but on incorrect types
-				{"ba", map[string]interface{}{"a": "100"}, false, nil},
-				{"bb", map[string]interface{}{"a": 100.0}, false, nil},
-				{"bc", map[string]interface{}{"a": map[string]interface{}{"b": 100}}, false, nil},
-			},
+// doesMatchTests are shared between all entry points which should behave as DoesMatch
+var doesMatchTests = []doesMatchTestCase{
+	// match on single
+	{
+		symbol: "AA",
+		query:  map[string]interface{}{"a": 100},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 100}, true, nil},  // straight match
+			{"ab", map[string]interface{}{"a": 201}, false, nil}, // incorrect value
+			{"ac", map[string]interface{}{"b": 200}, false, nil}, // missing key, the same value on different key
+			{"ad", map[string]interface{}{}, false, nil},         // empty data
+			{"ae", map[string]interface{}{"a": 100, "b": 101, "c": 102}, true, nil},
+			{"af", map[string]interface{}{"a": 200, "b": 201, "c": 202}, false, nil},
+			// match but on incorrect types
+			{"ba", map[string]interface{}{"a": "100"}, false, nil},
+			{"bb", map[string]interface{}{"a": 100.0}, true, nil}, // numbers are compared by value
+			{"bc", map[string]interface{}{"a": map[string]interface{}{"b": 100}}, false, nil},
+			{"bd", map[string]interface{}{"a": 100.5}, false, nil},
+			// numbers of other types
+			{"ca", map[string]interface{}{"a": int64(100)}, true, nil},
+			{"cb", map[string]interface{}{"a": uint8(100)}, true, nil},
+			{"cc", map[string]interface{}{"a": float32(100)}, true, nil},
+			{"cd", map[string]interface{}{"a": json.Number("100")}, true, nil},
+			{"ce", map[string]interface{}{"a": json.Number("1e2")}, true, nil},
+			{"cf", map[string]interface{}{"a": json.Number("100.1")}, false, nil},
 		},
-		// match on multiple
-		{
-			symbol: "AB",
-			query:  map[string]interface{}{"a": 100, "b": 101.0, "c": "102"},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 100, "b": 101.0, "c": "102"}, true, nil},
-				{"ab", map[string]interface{}{"a": "100", "b": 101.0, "c": "102"}, false, nil},
-				{"ac", map[string]interface{}{"a": 100, "b": 101, "c": "102"}, false, nil},
-				{"ad", map[string]interface{}{"a": 100, "b": 101.0, "c": 102}, false, nil},
-				{"ae", map[string]interface{}{"a": 100, "b": 101.0, "c": "102", "d": 501, "l1_e.l2_a": "502"}, true, nil},
-			},
+	},
+	// match on multiple
+	{
+		symbol: "AB",
+		query:  map[string]interface{}{"a": 100, "b": 101.0, "c": "102"},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 100, "b": 101.0, "c": "102"}, true, nil},
+			{"ab", map[string]interface{}{"a": "100", "b": 101.0, "c": "102"}, false, nil},
+			{"ac", map[string]interface{}{"a": 100, "b": 101, "c": "102"}, true, nil},
+			{"ad", map[string]interface{}{"a": 100, "b": 101.0, "c": 102}, false, nil},
+			{"ae", map[string]interface{}{"a": 100, "b": 101.0, "c": "102", "d": 501, "l1_e.l2_a": "502"}, true, nil},
 		},
-		// match on default values
-		{
-			symbol: "AC",
-			query:  map[string]interface{}{"a": 0, "b": 0.0, "c": ""},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 0, "b": 0.0, "c": ""}, true, nil},
-				{"ab", map[string]interface{}{"b": 0.0, "c": ""}, false, nil},
-				{"ac", map[string]interface{}{"a": 0, "c": ""}, false, nil},
-				{"ad", map[string]interface{}{"a": 0, "b": 0.0}, false, nil},
-			},
+	},
+	// match on default values
+	{
+		symbol: "AC",
+		query:  map[string]interface{}{"a": 0, "b": 0.0, "c": ""},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 0, "b": 0.0, "c": ""}, true, nil},
+			{"ab", map[string]interface{}{"b": 0.0, "c": ""}, false, nil},
+			{"ac", map[string]interface{}{"a": 0, "c": ""}, false, nil},
+			{"ad", map[string]interface{}{"a": 0, "b": 0.0}, false, nil},
 		},
-		// match on empty AND query -> should always match
-		{
-			symbol: "AD",
-			query:  map[string]interface{}{},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 0, "b": 0.0, "c": ""}, true, nil},
-				{"ab", map[string]interface{}{"b": 0.0, "c": ""}, true, nil},
-				{"ac", map[string]interface{}{}, true, nil},
-			},
+	},
+	// match on empty AND query -> should always match
+	{
+		symbol: "AD",
+		query:  map[string]interface{}{},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 0, "b": 0.0, "c": ""}, true, nil},
+			{"ab", map[string]interface{}{"b": 0.0, "c": ""}, true, nil},
+			{"ac", map[string]interface{}{}, true, nil},
 		},
-		// match on nested
-		{
-			symbol: "BA",
-			query:  map[string]interface{}{"l1_a.l2_a": 100},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"l1_a": map[string]interface{}{"l2_a": 100}}, true, nil},
-				{"ab", map[string]interface{}{"l1_a": map[string]interface{}{"l2_a": nil}}, false, nil},
-				{"aa", map[string]interface{}{"l1_a": map[string]interface{}{"l2_b": 100}}, false, nil},
-			},
+	},
+	// match on booleans
+	{
+		symbol: "AE",
+		query:  map[string]interface{}{"active": true},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"active": true}, true, nil},
+			{"ab", map[string]interface{}{"active": false}, false, nil},
+			{"ac", map[string]interface{}{}, false, nil},
+			{"ad", map[string]interface{}{"active": "true"}, false, nil},
+			{"ae", map[string]interface{}{"active": 1}, false, nil},
+			{"af", map[string]interface{}{"active": nil}, false, nil},
+		},
+	},
+	{
+		symbol: "AF",
+		query: map[string]interface{}{
+			"a": false,
+			"b": map[string]interface{}{"$not": true},
+			"c": []interface{}{true, "yes"},
 		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": false, "b": false, "c": true}, true, nil},
+			{"ab", map[string]interface{}{"a": false, "c": "yes"}, true, nil},
+			{"ba", map[string]interface{}{"a": true, "b": false, "c": true}, false, nil},
+			{"bb", map[string]interface{}{"a": false, "b": true, "c": true}, false, nil},
+			{"bc", map[string]interface{}{"a": false, "b": false, "c": false}, false, nil},
+			{"bd", map[string]interface{}{"b": false, "c": true}, false, nil},
+		},
+	},
+	// match on null, missing key is treated as null
+	{
+		symbol: "AG",
+		query:  map[string]interface{}{"deleted_at": nil},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"deleted_at": nil}, true, nil},
+			{"ab", map[string]interface{}{}, true, nil},
+			{"ac", map[string]interface{}{"deleted_at": "2016-01-01"}, false, nil},
+			{"ad", map[string]interface{}{"deleted_at": 0}, false, nil},
+			{"ae", map[string]interface{}{"deleted_at": false}, false, nil},
+			{"af", map[string]interface{}{"deleted_at": ""}, false, nil},
+		},
+	},
+	{
+		symbol: "AH",
+		query: map[string]interface{}{
+			"a": map[string]interface{}{"!$is": nil},
+			"b": []interface{}{nil, 1},
+			"c": map[string]interface{}{"$not": nil},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 1, "c": 1}, true, nil},
+			{"ab", map[string]interface{}{"a": 1, "b": 1, "c": 1}, true, nil},
+			{"ba", map[string]interface{}{"b": 1, "c": 1}, false, nil},
+			{"bb", map[string]interface{}{"a": nil, "b": 1, "c": 1}, false, nil},
+			{"bc", map[string]interface{}{"a": 1, "b": 2, "c": 1}, false, nil},
+			{"bd", map[string]interface{}{"a": 1, "b": 1, "c": nil}, false, nil},
+		},
+	},
+	// match on other numeric types in query
+	{
+		symbol: "AI",
+		query:  map[string]interface{}{"a": int64(100), "b": uint8(7), "c": json.Number("1.5")},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 100.0, "b": 7.0, "c": 1.5}, true, nil},
+			{"ab", map[string]interface{}{"a": 100, "b": 7, "c": json.Number("1.50")}, true, nil},
+			{"ba", map[string]interface{}{"a": 100.0, "b": 7.0, "c": 1.0}, false, nil},
+		},
+	},
+	// match on nested
+	{
+		symbol: "BA",
+		query:  map[string]interface{}{"l1_a.l2_a": 100},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"l1_a": map[string]interface{}{"l2_a": 100}}, true, nil},
+			{"ab", map[string]interface{}{"l1_a": map[string]interface{}{"l2_a": nil}}, false, nil},
+			{"aa", map[string]interface{}{"l1_a": map[string]interface{}{"l2_b": 100}}, false, nil},
+		},
+	},
+	// match on keys containing separator
+	{
+		symbol: "BB",
+		query:  map[string]interface{}{`l1_e\.l2_a`: "502", `labels.k8s\.io/name`: "api"},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"l1_e.l2_a": "502", "labels": map[string]interface{}{"k8s.io/name": "api"}}, true, nil},
+			{"ba", map[string]interface{}{"l1_e": map[string]interface{}{"l2_a": "502"}, "labels": map[string]interface{}{"k8s.io/name": "api"}}, false, nil},
+			{"bb", map[string]interface{}{"l1_e.l2_a": "502", "labels": map[string]interface{}{"k8s": map[string]interface{}{"io/name": "api"}}}, false, nil},
+		},
+	},
 
-		// $in: match on lists
-		{
-			symbol: "CA",
-			query:  map[string]interface{}{"a": []interface{}{100, 101, 102}},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 100}, true, nil},
-				{"ab", map[string]interface{}{"a": 101}, true, nil},
-				{"ac", map[string]interface{}{"a": 102}, true, nil},
-				{"ba", map[string]interface{}{"a": 200}, false, nil},
-				{"bb", map[string]interface{}{"b": 100}, false, nil},
-			},
+	// $in: match on lists
+	{
+		symbol: "CA",
+		query:  map[string]interface{}{"a": []interface{}{100, 101, 102}},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 100}, true, nil},
+			{"ab", map[string]interface{}{"a": 101}, true, nil},
+			{"ac", map[string]interface{}{"a": 102}, true, nil},
+			{"ba", map[string]interface{}{"a": 200}, false, nil},
+			{"bb", map[string]interface{}{"b": 100}, false, nil},
+			// numbers are compared by value
+			{"ca", map[string]interface{}{"a": 101.0}, true, nil},
+			{"cb", map[string]interface{}{"a": int32(102)}, true, nil},
+			{"cc", map[string]interface{}{"a": 101.5}, false, nil},
+			{"cd", map[string]interface{}{"a": "101"}, false, nil},
 		},
-		// $in: match on lists (deep)
-		{
-			symbol: "CB",
-			query:  map[string]interface{}{"l1_a.l2_a.l3_a": []interface{}{100, 101, 102}},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"l1_a": map[string]interface{}{"l2_a": map[string]interface{}{"l3_a": 101}}}, true, nil},
-				{"ba", map[string]interface{}{"l1_a": map[string]interface{}{"l2_a": map[string]interface{}{"l3_a": 0}}}, false, nil},
-				{"bb", map[string]interface{}{"l1_a": map[string]interface{}{"l2_a": map[string]interface{}{"l3_a": map[string]interface{}{"l4_a": 101}}}}, false, nil},
-			},
+	},
+	// $in: match on lists (deep)
+	{
+		symbol: "CB",
+		query:  map[string]interface{}{"l1_a.l2_a.l3_a": []interface{}{100, 101, 102}},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"l1_a": map[string]interface{}{"l2_a": map[string]interface{}{"l3_a": 101}}}, true, nil},
+			{"ba", map[string]interface{}{"l1_a": map[string]interface{}{"l2_a": map[string]interface{}{"l3_a": 0}}}, false, nil},
+			{"bb", map[string]interface{}{"l1_a": map[string]interface{}{"l2_a": map[string]interface{}{"l3_a": map[string]interface{}{"l4_a": 101}}}}, false, nil},
 		},
-		// $in: match via keyword
-		{
-			symbol: "CC",
-			query:  map[string]interface{}{"a": map[string]interface{}{"$in": []interface{}{100, 101, 102}}},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 100}, true, nil},
-				{"ab", map[string]interface{}{"a": 101}, true, nil},
-				{"ac", map[string]interface{}{"a": 102}, true, nil},
-				{"ba", map[string]interface{}{"a": 200}, false, nil},
-				{"bb", map[string]interface{}{"b": 100}, false, nil},
-			},
+	},
+	// $in: match via keyword
+	{
+		symbol: "CC",
+		query:  map[string]interface{}{"a": map[string]interface{}{"$in": []interface{}{100, 101, 102}}},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 100}, true, nil},
+			{"ab", map[string]interface{}{"a": 101}, true, nil},
+			{"ac", map[string]interface{}{"a": 102}, true, nil},
+			{"ba", map[string]interface{}{"a": 200}, false, nil},
+			{"bb", map[string]interface{}{"b": 100}, false, nil},
 		},
-		// $in: match via keyword (deep)
-		{
-			symbol: "CD",
-			query:  map[string]interface{}{"l1_a.l2_a.l3_a": map[string]interface{}{"$in": []interface{}{100, 101, 102}}},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"l1_a": map[string]interface{}{"l2_a": map[string]interface{}{"l3_a": 100}}}, true, nil},
-				{"ab", map[string]interface{}{"l1_a": map[string]interface{}{"l2_a": map[string]interface{}{"l3_a": 101}}}, true, nil},
-				{"ac", map[string]interface{}{"l1_a": map[string]interface{}{"l2_a": map[string]interface{}{"l3_a": 102}}}, true, nil},
-				{"ba", map[string]interface{}{"l1_a": map[string]interface{}{"l2_a": map[string]interface{}{"l3_a": 0}}}, false, nil},
-				{"bb", map[string]interface{}{"l1_a": map[string]interface{}{"l2_a": map[string]interface{}{"l3_a": map[string]interface{}{"l4_a": 101}}}}, false, nil},
-				{"bc", map[string]interface{}{"l1_a": map[string]interface{}{"l2_a": map[string]interface{}{"l3_a": map[string]interface{}{}}}}, false, nil},
-				{"bd", map[string]interface{}{"l1_a": map[string]interface{}{"l2_a": map[string]interface{}{"l3_a": []interface{}{}}}}, false, nil},
-			},
+	},
+	// $in: match via keyword (deep)
+	{
+		symbol: "CD",
+		query:  map[string]interface{}{"l1_a.l2_a.l3_a": map[string]interface{}{"$in": []interface{}{100, 101, 102}}},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"l1_a": map[string]interface{}{"l2_a": map[string]interface{}{"l3_a": 100}}}, true, nil},
+			{"ab", map[string]interface{}{"l1_a": map[string]interface{}{"l2_a": map[string]interface{}{"l3_a": 101}}}, true, nil},
+			{"ac", map[string]interface{}{"l1_a": map[string]interface{}{"l2_a": map[string]interface{}{"l3_a": 102}}}, true, nil},
+			{"ba", map[string]interface{}{"l1_a": map[string]interface{}{"l2_a": map[string]interface{}{"l3_a": 0}}}, false, nil},
+			{"bb", map[string]interface{}{"l1_a": map[string]interface{}{"l2_a": map[string]interface{}{"l3_a": map[string]interface{}{"l4_a": 101}}}}, false, nil},
+			{"bc", map[string]interface{}{"l1_a": map[string]interface{}{"l2_a": map[string]interface{}{"l3_a": map[string]interface{}{}}}}, false, nil},
+			{"bd", map[string]interface{}{"l1_a": map[string]interface{}{"l2_a": map[string]interface{}{"l3_a": []interface{}{}}}}, false, nil},
 		},
-		// !$in: match via keyword
-		{
-			symbol: "CE",
-			query:  map[string]interface{}{"a": map[string]interface{}{"!$in": []interface{}{100, 101, 102}}},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 100}, false, nil},
-				{"ab", map[string]interface{}{"a": 101}, false, nil},
-				{"ac", map[string]interface{}{"a": 102}, false, nil},
-				{"ba", map[string]interface{}{"a": 200}, true, nil},
-				{"bb", map[string]interface{}{"b": 100}, true, nil},
-			},
+	},
+	// !$in: match via keyword
+	{
+		symbol: "CE",
+		query:  map[string]interface{}{"a": map[string]interface{}{"!$in": []interface{}{100, 101, 102}}},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 100}, false, nil},
+			{"ab", map[string]interface{}{"a": 101}, false, nil},
+			{"ac", map[string]interface{}{"a": 102}, false, nil},
+			{"ba", map[string]interface{}{"a": 200}, true, nil},
+			{"bb", map[string]interface{}{"b": 100}, true, nil},
 		},
-		// $and: match via keyword
-		{
-			symbol: "DA",
-			query: map[string]interface{}{
-				"$and": []interface{}{
-					map[string]interface{}{"a": 101}, map[string]interface{}{"b": 102},
-				},
-			},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 101, "b": 102}, true, nil},
-				{"ab", map[string]interface{}{"a": 101, "b": 102, "c": 103}, true, nil},
-				{"ba", map[string]interface{}{"a": 201, "b": 102}, false, nil},
-				{"bb", map[string]interface{}{"a": 101, "b": 202}, false, nil},
-				{"ca", map[string]interface{}{"a": 101}, false, nil},
+	},
+	// $and: match via keyword
+	{
+		symbol: "DA",
+		query: map[string]interface{}{
+			"$and": []interface{}{
+				map[string]interface{}{"a": 101}, map[string]interface{}{"b": 102},
 			},
 		},
-		// $or: match on list
-		{
-			symbol: "EA",
-			query: map[string]interface{}{
-				"$or": []interface{}{
-					map[string]interface{}{"a": 101},
-					map[string]interface{}{"b": 102},
-				},
-			},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 101}, true, nil},
-				{"ab", map[string]interface{}{"b": 102}, true, nil},
-				{"ba", map[string]interface{}{"a": 201, "b": 102}, true, nil},
-				{"bb", map[string]interface{}{"a": 101, "b": 202}, true, nil},
-				{"ca", map[string]interface{}{"a": 201, "b": 202}, false, nil},
-				{"cb", map[string]interface{}{}, false, nil},
-			},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 101, "b": 102}, true, nil},
+			{"ab", map[string]interface{}{"a": 101, "b": 102, "c": 103}, true, nil},
+			{"ba", map[string]interface{}{"a": 201, "b": 102}, false, nil},
+			{"bb", map[string]interface{}{"a": 101, "b": 202}, false, nil},
+			{"ca", map[string]interface{}{"a": 101}, false, nil},
 		},
-		// $or: match on map
-		{
-			symbol: "EB",
-			query: map[string]interface{}{
-				"$or": map[string]interface{}{"a": 101, "b": 102},
-			},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 101}, true, nil},
-				{"ab", map[string]interface{}{"b": 102}, true, nil},
-				{"ba", map[string]interface{}{"a": 201, "b": 102}, true, nil},
-				{"bb", map[string]interface{}{"a": 101, "b": 202}, true, nil},
-				{"ca", map[string]interface{}{"a": 201, "b": 202}, false, nil},
-				{"cb", map[string]interface{}{}, false, nil},
+	},
+	// $or: match on list
+	{
+		symbol: "EA",
+		query: map[string]interface{}{
+			"$or": []interface{}{
+				map[string]interface{}{"a": 101},
+				map[string]interface{}{"b": 102},
 			},
 		},
-		// $or: match on empty list -> should never match
-		{
-			symbol: "EC",
-			query: map[string]interface{}{
-				"$or": map[string]interface{}{},
-			},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 101, "b": 102.0, "c": "103"}, false, nil},
-				{"ab", map[string]interface{}{"b": 0.0, "c": ""}, false, nil},
-				{"ac", map[string]interface{}{}, false, nil},
-			},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 101}, true, nil},
+			{"ab", map[string]interface{}{"b": 102}, true, nil},
+			{"ba", map[string]interface{}{"a": 201, "b": 102}, true, nil},
+			{"bb", map[string]interface{}{"a": 101, "b": 202}, true, nil},
+			{"ca", map[string]interface{}{"a": 201, "b": 202}, false, nil},
+			{"cb", map[string]interface{}{}, false, nil},
 		},
-		// $notAnd
-		{
-			symbol: "FA",
-			query:  map[string]interface{}{"$not": map[string]interface{}{"a": 100}},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 200}, true, nil},
-				{"ab", map[string]interface{}{"a": 100}, false, nil},
-				{"ac", map[string]interface{}{}, true, nil},
-				{"ad", map[string]interface{}{"b": 100}, true, nil},
-			},
+	},
+	// $or: match on map
+	{
+		symbol: "EB",
+		query: map[string]interface{}{
+			"$or": map[string]interface{}{"a": 101, "b": 102},
 		},
-		// $andNot
-		{
-			symbol: "FB",
-			query:  map[string]interface{}{"a": map[string]interface{}{"$not": 100}},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 200}, true, nil},
-				{"ab", map[string]interface{}{"a": 100}, false, nil},
-				{"ac", map[string]interface{}{}, true, nil},
-			},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 101}, true, nil},
+			{"ab", map[string]interface{}{"b": 102}, true, nil},
+			{"ba", map[string]interface{}{"a": 201, "b": 102}, true, nil},
+			{"bb", map[string]interface{}{"a": 101, "b": 202}, true, nil},
+			{"ca", map[string]interface{}{"a": 201, "b": 202}, false, nil},
+			{"cb", map[string]interface{}{}, false, nil},
 		},
-		// $not in list
-		{
-			symbol: "FC",
-			query:  map[string]interface{}{"a": map[string]interface{}{"$not": []interface{}{100, 101, 102}}},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 200}, true, nil},
-				{"ab", map[string]interface{}{"a": 100}, false, nil},
-				{"ac", map[string]interface{}{}, true, nil},
-				{"ad", map[string]interface{}{"b": 100}, true, nil},
-			},
+	},
+	// $or: match on empty list -> should never match
+	{
+		symbol: "EC",
+		query: map[string]interface{}{
+			"$or": map[string]interface{}{},
 		},
-		// !$and
-		{
-			symbol: "GA",
-			query: map[string]interface{}{
-				"!$and": []interface{}{
-					map[string]interface{}{"a": 101}, map[string]interface{}{"b": 102},
-				},
-			},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 101, "b": 102}, false, nil},
-				{"ab", map[string]interface{}{"a": 101, "b": 102, "c": 103}, false, nil},
-				{"ba", map[string]interface{}{"a": 201, "b": 102}, true, nil},
-				{"bb", map[string]interface{}{"a": 101, "b": 202}, true, nil},
-				{"ca", map[string]interface{}{"a": 101}, true, nil},
-			},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 101, "b": 102.0, "c": "103"}, false, nil},
+			{"ab", map[string]interface{}{"b": 0.0, "c": ""}, false, nil},
+			{"ac", map[string]interface{}{}, false, nil},
 		},
-		// !!$and (double negation)
-		{
-			symbol: "GB",
-			query: map[string]interface{}{
-				"!!$and": []interface{}{
-					map[string]interface{}{"a": 101}, map[string]interface{}{"b": 102},
-				},
-			},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 101, "b": 102}, true, nil},
-				{"ab", map[string]interface{}{"a": 101, "b": 102, "c": 103}, true, nil},
-				{"ba", map[string]interface{}{"a": 201, "b": 102}, false, nil},
-				{"bb", map[string]interface{}{"a": 101, "b": 202}, false, nil},
-				{"ca", map[string]interface{}{"a": 101}, false, nil},
-			},
+	},
+	// $notAnd
+	{
+		symbol: "FA",
+		query:  map[string]interface{}{"$not": map[string]interface{}{"a": 100}},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 200}, true, nil},
+			{"ab", map[string]interface{}{"a": 100}, false, nil},
+			{"ac", map[string]interface{}{}, true, nil},
+			{"ad", map[string]interface{}{"b": 100}, true, nil},
 		},
-		// $and $is
-		{
-			symbol: "GC",
-			query: map[string]interface{}{
-				"a": map[string]interface{}{"$is": 101},
-			},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 101}, true, nil},
-				{"ab", map[string]interface{}{"a": 201}, false, nil},
-				{"ac", map[string]interface{}{"b": 101}, false, nil},
-				{"ad", map[string]interface{}{}, false, nil},
-			},
+	},
+	// $andNot
+	{
+		symbol: "FB",
+		query:  map[string]interface{}{"a": map[string]interface{}{"$not": 100}},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 200}, true, nil},
+			{"ab", map[string]interface{}{"a": 100}, false, nil},
+			{"ad", map[string]interface{}{"a": 100.0}, false, nil},
+			{"ac", map[string]interface{}{}, true, nil},
 		},
-		// $and !$is
-		{
-			symbol: "GD",
-			query: map[string]interface{}{
-				"a": map[string]interface{}{"!$is": 101},
-			},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 101}, false, nil},
-				{"ab", map[string]interface{}{"a": 201}, true, nil},
-				{"ac", map[string]interface{}{"b": 101}, true, nil},
-				{"ad", map[string]interface{}{}, true, nil},
-			},
+	},
+	// $not in list
+	{
+		symbol: "FC",
+		query:  map[string]interface{}{"a": map[string]interface{}{"$not": []interface{}{100, 101, 102}}},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 200}, true, nil},
+			{"ab", map[string]interface{}{"a": 100}, false, nil},
+			{"ae", map[string]interface{}{"a": 100.0}, false, nil},
+			{"af", map[string]interface{}{"a": json.Number("101")}, false, nil},
+			{"ac", map[string]interface{}{}, true, nil},
+			{"ad", map[string]interface{}{"b": 100}, true, nil},
 		},
-		// $and !!$is
-		{
-			symbol: "GE",
-			query: map[string]interface{}{
-				"a": map[string]interface{}{"!!$is": 101},
-			},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 101}, true, nil},
+	},
+	// !$and
+	{
+		symbol: "GA",
+		query: map[string]interface{}{
+			"!$and": []interface{}{
+				map[string]interface{}{"a": 101}, map[string]interface{}{"b": 102},
 			},
 		},
-		// multiple matchers/comparator on one level
-		// TODO: change after implementing
-		{
-			symbol: "HA",
-			query: map[string]interface{}{
-				"a": map[string]interface{}{
-					"$in":  []interface{}{101, 102},
-					"$not": 201,
-				},
-			},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 101}, false, errors.New("matchValue: multiple expectations")},
-			},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 101, "b": 102}, false, nil},
+			{"ab", map[string]interface{}{"a": 101, "b": 102, "c": 103}, false, nil},
+			{"ba", map[string]interface{}{"a": 201, "b": 102}, true, nil},
+			{"bb", map[string]interface{}{"a": 101, "b": 202}, true, nil},
+			{"ca", map[string]interface{}{"a": 101}, true, nil},
 		},
-		// TODO: change after implementing
-		{
-			symbol: "HB",
-			query: map[string]interface{}{
-				"a": map[string]interface{}{
-					"$in":  []interface{}{101, 102},
-					"$not": 101,
-				},
-			},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 101}, false, errors.New("matchValue: multiple expectations")},
-			},
-			//			debug: true,
-		},
-		// arithmetic comparators
-		// $gt as Int
-		{
-			symbol: "IA",
-			query: map[string]interface{}{
-				"a": map[string]interface{}{"$gt": 105},
-			},
-			tests: []subTestCase{
-				// native type
-				{"aa", map[string]interface{}{"a": 206}, true, nil},
-				{"ab", map[string]interface{}{"a": 105}, false, nil},
-				{"ac", map[string]interface{}{"a": 100}, false, nil},
-				{"ad", map[string]interface{}{"a": 0}, false, nil},
-				{"ae", map[string]interface{}{"a": -100}, false, nil},
-				{"af", map[string]interface{}{"a": -105}, false, nil},
-				{"ag", map[string]interface{}{"a": -106}, false, nil},
-				// automatic casting to native type
-				{"ba", map[string]interface{}{"a": 206.0}, true, nil},
-				{"bb", map[string]interface{}{"a": 105.0}, false, nil},
-				{"bc", map[string]interface{}{"a": 100.0}, false, nil},
-				{"bd", map[string]interface{}{"a": 0.0}, false, nil},
-				{"be", map[string]interface{}{"a": -100.0}, false, nil},
-				{"bf", map[string]interface{}{"a": -105.0}, false, nil},
-				{"bg", map[string]interface{}{"a": -106.0}, false, nil},
-				// casting impossible
-				{"ca", map[string]interface{}{"a": "206"}, false, errors.New("comparator: casting actual to Int failed.")},
-				{"cb", map[string]interface{}{"a": nil}, false, errors.New("comparator: casting actual to Int failed.")},
+	},
+	// !!$and (double negation)
+	{
+		symbol: "GB",
+		query: map[string]interface{}{
+			"!!$and": []interface{}{
+				map[string]interface{}{"a": 101}, map[string]interface{}{"b": 102},
 			},
 		},
-		// $gt as float64
-		{
-			symbol: "IB",
-			query: map[string]interface{}{
-				"a": map[string]interface{}{"$gt": 105.3},
-			},
-			tests: []subTestCase{
-				// native type
-				{"aa", map[string]interface{}{"a": 206.0}, true, nil},
-				{"ab", map[string]interface{}{"a": 105.0}, false, nil},
-				{"ac", map[string]interface{}{"a": 100.0}, false, nil},
-				{"ad", map[string]interface{}{"a": 0.0}, false, nil},
-				{"ae", map[string]interface{}{"a": -100.0}, false, nil},
-				{"af", map[string]interface{}{"a": -105.0}, false, nil},
-				{"ag", map[string]interface{}{"a": -106.0}, false, nil},
-				// automatic casting to native type
-				{"ba", map[string]interface{}{"a": 206}, true, nil},
-				{"bb", map[string]interface{}{"a": 100}, false, nil},
-				{"bc", map[string]interface{}{"a": 0}, false, nil},
-				{"be", map[string]interface{}{"a": -100}, false, nil},
-				{"bf", map[string]interface{}{"a": -105}, false, nil},
-				{"bg", map[string]interface{}{"a": -106}, false, nil},
-				// casting impossible
-				{"ca", map[string]interface{}{"a": "206"}, false, errors.New("comparator: casting actual to Float64 failed.")},
-				{"cb", map[string]interface{}{"a": nil}, false, errors.New("comparator: casting actual to Float64 failed.")},
-			},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 101, "b": 102}, true, nil},
+			{"ab", map[string]interface{}{"a": 101, "b": 102, "c": 103}, true, nil},
+			{"ba", map[string]interface{}{"a": 201, "b": 102}, false, nil},
+			{"bb", map[string]interface{}{"a": 101, "b": 202}, false, nil},
+			{"ca", map[string]interface{}{"a": 101}, false, nil},
 		},
-		// $gte as int
-		{
-			symbol: "IC",
-			query: map[string]interface{}{
-				"a": map[string]interface{}{"$gte": 105},
-			},
-			tests: []subTestCase{
-				// native type
-				{"aa", map[string]interface{}{"a": 206}, true, nil},
-				{"ab", map[string]interface{}{"a": 105}, true, nil},
-				{"ac", map[string]interface{}{"a": 100}, false, nil},
-				// automatic casting to native type
-				{"ba", map[string]interface{}{"a": 206.0}, true, nil},
-				{"bb", map[string]interface{}{"a": 105.0}, true, nil},
-				{"bc", map[string]interface{}{"a": 100.0}, false, nil},
-				// casting impossible
-				{"ca", map[string]interface{}{"a": "206"}, false, errors.New("comparator: casting actual to Int failed.")},
-				{"cb", map[string]interface{}{"a": nil}, false, errors.New("comparator: casting actual to Int failed.")},
-			},
+	},
+	// $and $is
+	{
+		symbol: "GC",
+		query: map[string]interface{}{
+			"a": map[string]interface{}{"$is": 101},
 		},
-		// $gte as float64
-		{
-			symbol: "ID",
-			query: map[string]interface{}{
-				"a": map[string]interface{}{"$gte": 105.0},
-			},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 206.0}, true, nil},
-				{"ab", map[string]interface{}{"a": 105.0}, true, nil},
-				{"ac", map[string]interface{}{"a": 100.0}, false, nil},
-				{"ad", map[string]interface{}{"a": 100}, false, nil},
-			},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 101}, true, nil},
+			{"ab", map[string]interface{}{"a": 201}, false, nil},
+			{"ac", map[string]interface{}{"b": 101}, false, nil},
+			{"ad", map[string]interface{}{}, false, nil},
 		},
-		// $lt as int
-		{
-			symbol: "IE",
-			query: map[string]interface{}{
-				"a": map[string]interface{}{"$lt": 105},
-			},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 104}, true, nil},
-				{"ab", map[string]interface{}{"a": 105}, false, nil},
-				{"ac", map[string]interface{}{"a": 106}, false, nil},
-				{"ac", map[string]interface{}{"a": 106.0}, false, nil},
-			},
+	},
+	// $and !$is
+	{
+		symbol: "GD",
+		query: map[string]interface{}{
+			"a": map[string]interface{}{"!$is": 101},
 		},
-		// $lt as float
-		{
-			symbol: "IF",
-			query: map[string]interface{}{
-				"a": map[string]interface{}{"$lt": 105.0},
-			},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 104.0}, true, nil},
-				{"ab", map[string]interface{}{"a": 105.0}, false, nil},
-				{"ac", map[string]interface{}{"a": 106}, false, nil},
-				{"ac", map[string]interface{}{"a": 106.0}, false, nil},
-			},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 101}, false, nil},
+			{"ab", map[string]interface{}{"a": 201}, true, nil},
+			{"ac", map[string]interface{}{"b": 101}, true, nil},
+			{"ad", map[string]interface{}{}, true, nil},
 		},
-		// $lte as int
-		{
-			symbol: "IG",
-			query: map[string]interface{}{
-				"a": map[string]interface{}{"$lte": 105},
-			},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 104}, true, nil},
-				{"ab", map[string]interface{}{"a": 105}, true, nil},
-				{"ac", map[string]interface{}{"a": 106}, false, nil},
-				{"ac", map[string]interface{}{"a": 106.0}, false, nil},
-			},
+	},
+	// $and !!$is
+	{
+		symbol: "GE",
+		query: map[string]interface{}{
+			"a": map[string]interface{}{"!!$is": 101},
 		},
-		// $lte as int
-		{
-			symbol: "IH",
-			query: map[string]interface{}{
-				"a": map[string]interface{}{"$lte": 105.0},
-			},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 104.0}, true, nil},
-				{"ab", map[string]interface{}{"a": 105.0}, true, nil},
-				{"ac", map[string]interface{}{"a": 106}, false, nil},
-				{"ac", map[string]interface{}{"a": 106.0}, false, nil},
-			},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 101}, true, nil},
 		},
-		// $unknownComparator
-		{
-			symbol: "ZA",
-			query:  map[string]interface{}{"$unknownComparator": 101},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 101}, false, errors.New("matchComparator: unknown comparator")},
+	},
+	// multiple matchers/comparator on one level
+	{
+		symbol: "HA",
+		query: map[string]interface{}{
+			"a": map[string]interface{}{
+				"$in":  []interface{}{101, 102},
+				"$not": 201,
 			},
 		},
-		// $and with unknown structure -> failed due to unknown structure
-		{
-			symbol: "ZB",
-			query:  map[string]interface{}{"$and": 101},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 101}, false, errors.New("matcherAnd: unknown query type")},
-			},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 101}, true, nil},
+			{"ab", map[string]interface{}{"a": 102}, true, nil},
+			{"ac", map[string]interface{}{"a": 201}, false, nil},
+			{"ad", map[string]interface{}{"a": 301}, false, nil},
+			{"ae", map[string]interface{}{}, false, nil},
 		},
-		// $or with unknown structure -> failed due to unknown structure
-		{
-			symbol: "ZC",
-			query:  map[string]interface{}{"$or": 101},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 101}, false, errors.New("matcherAnd: unknown query type")},
+	},
+	{
+		symbol: "HB",
+		query: map[string]interface{}{
+			"a": map[string]interface{}{
+				"$in":  []interface{}{101, 102},
+				"$not": 101,
 			},
 		},
-		// $in with unknown structure
-		{
-			symbol: "ZC",
-			query:  map[string]interface{}{"$in": 101},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 101}, false, errors.New("comparatorIn: unknown expected type")},
-			},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 101}, false, nil},
+			{"ab", map[string]interface{}{"a": 102}, true, nil},
+			{"ac", map[string]interface{}{"a": 103}, false, nil},
 		},
-		{
-			symbol: "ZD",
-			query:  map[string]interface{}{"!$unknown": 101},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 101}, false, errors.New("matchComparator: unknown comparator")},
+	},
+	// range via multiple comparators
+	{
+		symbol: "HC",
+		query: map[string]interface{}{
+			"age": map[string]interface{}{
+				"$gte": 18,
+				"$lt":  65,
 			},
 		},
-		{
-			symbol: "ZE",
-			query:  map[string]interface{}{"$not": map[string]interface{}{"$unknown": 101}},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 101}, false, errors.New("matchComparator: unknown comparator")},
-			},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"age": 18}, true, nil},
+			{"ab", map[string]interface{}{"age": 64}, true, nil},
+			{"ac", map[string]interface{}{"age": 40.0}, true, nil},
+			{"ba", map[string]interface{}{"age": 17}, false, nil},
+			{"bb", map[string]interface{}{"age": 65}, false, nil},
 		},
-		{
-			symbol: "ZF",
-			query:  map[string]interface{}{"a": map[int]interface{}{123: 101}},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 101}, false, errors.New("matchValue: not a map")},
+	},
+	// multiple comparators with negation
+	{
+		symbol: "HD",
+		query: map[string]interface{}{
+			"a": map[string]interface{}{
+				"!$in":  []interface{}{101, 102},
+				"!$is":  201,
+				"$gt":   100,
+				"!$gte": 300,
 			},
 		},
-		{
-			symbol: "ZG",
-			query: []interface{}{
-				map[string]interface{}{
-					"$unknown": 101,
-				},
-			},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 101}, false, errors.New("matchComparator: unknown comparator")},
-			},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 150}, true, nil},
+			{"ab", map[string]interface{}{"a": 299}, true, nil},
+			{"ba", map[string]interface{}{"a": 101}, false, nil},
+			{"bb", map[string]interface{}{"a": 201}, false, nil},
+			{"bc", map[string]interface{}{"a": 100}, false, nil},
+			{"bd", map[string]interface{}{"a": 300}, false, nil},
 		},
-		{
-			symbol: "ZH",
-			query: map[string]interface{}{
-				"$or": map[string]interface{}{
-					"$unknown": 101,
-				},
-			},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 101}, false, errors.New("matchComparator: unknown comparator")},
-			},
+	},
+	// empty comparators map -> nothing to fail
+	{
+		symbol: "HE",
+		query: map[string]interface{}{
+			"a": map[string]interface{}{},
 		},
-		{
-			symbol: "ZI",
-			query: map[string]interface{}{
-				"$or": []interface{}{101},
-			},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 101}, false, errors.New("matcherAnd: unknown query type")},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 101}, true, nil},
+			{"ab", map[string]interface{}{}, true, nil},
+		},
+	},
+	// arithmetic comparators
+	// $gt as Int
+	{
+		symbol: "IA",
+		query: map[string]interface{}{
+			"a": map[string]interface{}{"$gt": 105},
+		},
+		tests: []doesMatchSubTestCase{
+			// native type
+			{"aa", map[string]interface{}{"a": 206}, true, nil},
+			{"ab", map[string]interface{}{"a": 105}, false, nil},
+			{"ac", map[string]interface{}{"a": 100}, false, nil},
+			{"ad", map[string]interface{}{"a": 0}, false, nil},
+			{"ae", map[string]interface{}{"a": -100}, false, nil},
+			{"af", map[string]interface{}{"a": -105}, false, nil},
+			{"ag", map[string]interface{}{"a": -106}, false, nil},
+			// automatic casting to native type
+			{"ba", map[string]interface{}{"a": 206.0}, true, nil},
+			{"bb", map[string]interface{}{"a": 105.0}, false, nil},
+			{"bc", map[string]interface{}{"a": 100.0}, false, nil},
+			{"bd", map[string]interface{}{"a": 0.0}, false, nil},
+			{"be", map[string]interface{}{"a": -100.0}, false, nil},
+			{"bf", map[string]interface{}{"a": -105.0}, false, nil},
+			{"bg", map[string]interface{}{"a": -106.0}, false, nil},
+			// casting impossible
+			{"ca", map[string]interface{}{"a": "206"}, false, ErrTypeMismatch},
+			{"cb", map[string]interface{}{"a": nil}, false, ErrTypeMismatch},
+		},
+	},
+	// $gt as float64
+	{
+		symbol: "IB",
+		query: map[string]interface{}{
+			"a": map[string]interface{}{"$gt": 105.3},
+		},
+		tests: []doesMatchSubTestCase{
+			// native type
+			{"aa", map[string]interface{}{"a": 206.0}, true, nil},
+			{"ab", map[string]interface{}{"a": 105.0}, false, nil},
+			{"ac", map[string]interface{}{"a": 100.0}, false, nil},
+			{"ad", map[string]interface{}{"a": 0.0}, false, nil},
+			{"ae", map[string]interface{}{"a": -100.0}, false, nil},
+			{"af", map[string]interface{}{"a": -105.0}, false, nil},
+			{"ag", map[string]interface{}{"a": -106.0}, false, nil},
+			// automatic casting to native type
+			{"ba", map[string]interface{}{"a": 206}, true, nil},
+			{"bb", map[string]interface{}{"a": 100}, false, nil},
+			{"bc", map[string]interface{}{"a": 0}, false, nil},
+			{"be", map[string]interface{}{"a": -100}, false, nil},
+			{"bf", map[string]interface{}{"a": -105}, false, nil},
+			{"bg", map[string]interface{}{"a": -106}, false, nil},
+			// casting impossible
+			{"ca", map[string]interface{}{"a": "206"}, false, ErrTypeMismatch},
+			{"cb", map[string]interface{}{"a": nil}, false, ErrTypeMismatch},
+		},
+	},
+	// $gte as int
+	{
+		symbol: "IC",
+		query: map[string]interface{}{
+			"a": map[string]interface{}{"$gte": 105},
+		},
+		tests: []doesMatchSubTestCase{
+			// native type
+			{"aa", map[string]interface{}{"a": 206}, true, nil},
+			{"ab", map[string]interface{}{"a": 105}, true, nil},
+			{"ac", map[string]interface{}{"a": 100}, false, nil},
+			// automatic casting to native type
+			{"ba", map[string]interface{}{"a": 206.0}, true, nil},
+			{"bb", map[string]interface{}{"a": 105.0}, true, nil},
+			{"bc", map[string]interface{}{"a": 100.0}, false, nil},
+			// casting impossible
+			{"ca", map[string]interface{}{"a": "206"}, false, ErrTypeMismatch},
+			{"cb", map[string]interface{}{"a": nil}, false, ErrTypeMismatch},
+		},
+	},
+	// $gte as float64
+	{
+		symbol: "ID",
+		query: map[string]interface{}{
+			"a": map[string]interface{}{"$gte": 105.0},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 206.0}, true, nil},
+			{"ab", map[string]interface{}{"a": 105.0}, true, nil},
+			{"ac", map[string]interface{}{"a": 100.0}, false, nil},
+			{"ad", map[string]interface{}{"a": 100}, false, nil},
+		},
+	},
+	// $lt as int
+	{
+		symbol: "IE",
+		query: map[string]interface{}{
+			"a": map[string]interface{}{"$lt": 105},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 104}, true, nil},
+			{"ab", map[string]interface{}{"a": 105}, false, nil},
+			{"ac", map[string]interface{}{"a": 106}, false, nil},
+			{"ac", map[string]interface{}{"a": 106.0}, false, nil},
+		},
+	},
+	// $lt as float
+	{
+		symbol: "IF",
+		query: map[string]interface{}{
+			"a": map[string]interface{}{"$lt": 105.0},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 104.0}, true, nil},
+			{"ab", map[string]interface{}{"a": 105.0}, false, nil},
+			{"ac", map[string]interface{}{"a": 106}, false, nil},
+			{"ac", map[string]interface{}{"a": 106.0}, false, nil},
+		},
+	},
+	// $lte as int
+	{
+		symbol: "IG",
+		query: map[string]interface{}{
+			"a": map[string]interface{}{"$lte": 105},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 104}, true, nil},
+			{"ab", map[string]interface{}{"a": 105}, true, nil},
+			{"ac", map[string]interface{}{"a": 106}, false, nil},
+			{"ac", map[string]interface{}{"a": 106.0}, false, nil},
+		},
+	},
+	// $lte as int
+	{
+		symbol: "IH",
+		query: map[string]interface{}{
+			"a": map[string]interface{}{"$lte": 105.0},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 104.0}, true, nil},
+			{"ab", map[string]interface{}{"a": 105.0}, true, nil},
+			{"ac", map[string]interface{}{"a": 106}, false, nil},
+			{"ac", map[string]interface{}{"a": 106.0}, false, nil},
+		},
+	},
+	// $unknownComparator
+	{
+		symbol: "ZA",
+		query:  map[string]interface{}{"$unknownComparator": 101},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 101}, false, ErrUnknownComparator},
+		},
+	},
+	// $and with unknown structure -> failed due to unknown structure
+	{
+		symbol: "ZB",
+		query:  map[string]interface{}{"$and": 101},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 101}, false, ErrUnknownQueryType},
+		},
+	},
+	// $or with unknown structure -> failed due to unknown structure
+	{
+		symbol: "ZC",
+		query:  map[string]interface{}{"$or": 101},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 101}, false, ErrUnknownQueryType},
+		},
+	},
+	// $in with unknown structure
+	{
+		symbol: "ZC",
+		query:  map[string]interface{}{"$in": 101},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 101}, false, ErrUnknownExpectedType},
+		},
+	},
+	{
+		symbol: "ZD",
+		query:  map[string]interface{}{"!$unknown": 101},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 101}, false, ErrUnknownComparator},
+		},
+	},
+	{
+		symbol: "ZE",
+		query:  map[string]interface{}{"$not": map[string]interface{}{"$unknown": 101}},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 101}, false, ErrUnknownComparator},
+		},
+	},
+	{
+		symbol: "ZF",
+		query:  map[string]interface{}{"a": map[int]interface{}{123: 101}},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 101}, false, ErrNotAMap},
+		},
+	},
+	{
+		symbol: "ZG",
+		query: []interface{}{
+			map[string]interface{}{
+				"$unknown": 101,
 			},
 		},
-		{
-			symbol: "ZJ",
-			query: map[string]interface{}{
-				"a": map[string]interface{}{"$lt": "105"},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 101}, false, ErrUnknownComparator},
+		},
+	},
+	{
+		symbol: "ZH",
+		query: map[string]interface{}{
+			"$or": map[string]interface{}{
+				"$unknown": 101,
 			},
-			tests: []subTestCase{
-				{"aa", map[string]interface{}{"a": 101}, false, errors.New("comparator: unknown type (type: string)")},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 101}, false, ErrUnknownComparator},
+		},
+	},
+	{
+		symbol: "ZI",
+		query: map[string]interface{}{
+			"$or": []interface{}{101},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 101}, false, ErrUnknownQueryType},
+		},
+	},
+	{
+		symbol: "ZJ",
+		query: map[string]interface{}{
+			"a": map[string]interface{}{"$lt": []interface{}{105}},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 101}, false, ErrUnknownExpectedType},
+		},
+	},
+
+	// $contains on string
+	{
+		symbol: "JA",
+		query: map[string]interface{}{
+			"a": map[string]interface{}{"$contains": "ell"},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": "hello"}, true, nil},
+			{"ab", map[string]interface{}{"a": "ell"}, true, nil},
+			{"ac", map[string]interface{}{"a": "help"}, false, nil},
+			{"ad", map[string]interface{}{"a": ""}, false, nil},
+			{"ae", map[string]interface{}{}, false, nil},
+			{"af", map[string]interface{}{"a": nil}, false, nil},
+			// unsupported types
+			{"ba", map[string]interface{}{"a": 101}, false, ErrTypeMismatch},
+			{"bb", map[string]interface{}{"a": map[string]interface{}{"ell": 1}}, false, ErrTypeMismatch},
+		},
+	},
+	// $contains on list
+	{
+		symbol: "JB",
+		query: map[string]interface{}{
+			"tags": map[string]interface{}{"$contains": "x"},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"tags": []interface{}{"x"}}, true, nil},
+			{"ab", map[string]interface{}{"tags": []interface{}{"a", "b", "x"}}, true, nil},
+			{"ac", map[string]interface{}{"tags": []interface{}{"a", "b"}}, false, nil},
+			{"ad", map[string]interface{}{"tags": []interface{}{}}, false, nil},
+			{"ae", map[string]interface{}{"tags": []interface{}{"xx", 1}}, false, nil},
+			// substring on string value
+			{"ba", map[string]interface{}{"tags": "xyz"}, true, nil},
+		},
+	},
+	// $contains with non string expectation
+	{
+		symbol: "JC",
+		query: map[string]interface{}{
+			"a": map[string]interface{}{"$contains": 101},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": []interface{}{100, 101}}, true, nil},
+			{"ab", map[string]interface{}{"a": []interface{}{"101"}}, false, nil},
+			{"ba", map[string]interface{}{"a": "101"}, false, ErrTypeMismatch},
+		},
+	},
+	// !$contains
+	{
+		symbol: "JD",
+		query: map[string]interface{}{
+			"tags": map[string]interface{}{"!$contains": "x"},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"tags": []interface{}{"x"}}, false, nil},
+			{"ab", map[string]interface{}{"tags": []interface{}{"a", "b"}}, true, nil},
+			{"ac", map[string]interface{}{"tags": "xyz"}, false, nil},
+			{"ad", map[string]interface{}{"tags": "abc"}, true, nil},
+			{"ae", map[string]interface{}{}, true, nil},
+		},
+	},
+	// $and $contains
+	{
+		symbol: "JE",
+		query: map[string]interface{}{
+			"$and": []interface{}{
+				map[string]interface{}{"tags": map[string]interface{}{"$contains": "x"}},
+				map[string]interface{}{"tags": map[string]interface{}{"$contains": "y"}},
 			},
-			debug: true,
 		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"tags": []interface{}{"x", "y"}}, true, nil},
+			{"ab", map[string]interface{}{"tags": []interface{}{"x"}}, false, nil},
+			{"ac", map[string]interface{}{"tags": []interface{}{"y"}}, false, nil},
+		},
+	},
+	// $contains with object and list expectations
+	{
+		symbol: "JF",
+		query: map[string]interface{}{
+			"tags":  map[string]interface{}{"$contains": map[string]interface{}{"x": 1}},
+			"lists": map[string]interface{}{"$contains": []interface{}{"a"}},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"tags": []interface{}{map[string]interface{}{"x": 1}}, "lists": []interface{}{[]interface{}{"a"}}}, true, nil},
+			{"ab", map[string]interface{}{"tags": []interface{}{"x", map[string]interface{}{"x": 1.0}}, "lists": []interface{}{"a", []interface{}{"a"}}}, true, nil},
+			{"ac", map[string]interface{}{"tags": []interface{}{map[string]interface{}{"x": 2}}, "lists": []interface{}{[]interface{}{"a"}}}, false, nil},
+			{"ad", map[string]interface{}{"tags": []interface{}{map[string]interface{}{"x": 1, "y": 2}}, "lists": []interface{}{[]interface{}{"a"}}}, false, nil},
+			{"ae", map[string]interface{}{"tags": []interface{}{map[string]interface{}{"x": 1}}, "lists": []interface{}{[]interface{}{"a", "b"}}}, false, nil},
+		},
+	},
+	// $in with objects and lists
+	{
+		symbol: "JG",
+		query: map[string]interface{}{
+			"a": []interface{}{map[string]interface{}{"x": 1}, []interface{}{1, 2}, 3},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": map[string]interface{}{"x": 1}}, true, nil},
+			{"ab", map[string]interface{}{"a": []interface{}{1.0, 2.0}}, true, nil},
+			{"ac", map[string]interface{}{"a": 3}, true, nil},
+			{"ad", map[string]interface{}{"a": map[string]interface{}{"x": 2}}, false, nil},
+			{"ae", map[string]interface{}{"a": []interface{}{2, 1}}, false, nil},
+			{"af", map[string]interface{}{"a": map[string]interface{}{}}, false, nil},
+		},
+	},
+	// $exists
+	{
+		symbol: "KA",
+		query: map[string]interface{}{
+			"email": map[string]interface{}{"$exists": true},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"email": "a@b.c"}, true, nil},
+			{"ab", map[string]interface{}{"email": ""}, true, nil},
+			{"ac", map[string]interface{}{"email": nil}, true, nil},
+			{"ba", map[string]interface{}{}, false, nil},
+			{"bb", map[string]interface{}{"e-mail": "a@b.c"}, false, nil},
+		},
+	},
+	{
+		symbol: "KB",
+		query: map[string]interface{}{
+			"l1_a.l2_a": map[string]interface{}{"$exists": false},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{}, true, nil},
+			{"ab", map[string]interface{}{"l1_a": 1}, true, nil},
+			{"ac", map[string]interface{}{"l1_a": map[string]interface{}{}}, true, nil},
+			{"ba", map[string]interface{}{"l1_a": map[string]interface{}{"l2_a": nil}}, false, nil},
+			{"bb", map[string]interface{}{"l1_a": map[string]interface{}{"l2_a": 1}}, false, nil},
+		},
+	},
+	// !$exists
+	{
+		symbol: "KC",
+		query: map[string]interface{}{
+			"email": map[string]interface{}{"!$exists": true},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{}, true, nil},
+			{"ba", map[string]interface{}{"email": nil}, false, nil},
+			{"bb", map[string]interface{}{"email": "a@b.c"}, false, nil},
+		},
+	},
+	// $exists combined with other comparators, null is present but is not a value
+	{
+		symbol: "KD",
+		query: map[string]interface{}{
+			"a": map[string]interface{}{"$exists": true, "$is": nil},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": nil}, true, nil},
+			{"ba", map[string]interface{}{}, false, nil},
+			{"bb", map[string]interface{}{"a": 1}, false, nil},
+		},
+	},
+	{
+		symbol: "KE",
+		query: map[string]interface{}{
+			"a": map[string]interface{}{"$exists": 1},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 1}, false, ErrUnknownExpectedType},
+		},
+	},
+	// list index in path
+	{
+		symbol: "LA",
+		query: map[string]interface{}{
+			"items.0.sku":   "a1",
+			"items.-1.sku":  map[string]interface{}{"$in": []interface{}{"b2", "c3"}},
+			"items.1.price": map[string]interface{}{"$gt": 15},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"items": []interface{}{
+				map[string]interface{}{"sku": "a1"},
+				map[string]interface{}{"sku": "b2", "price": 20.0},
+			}}, true, nil},
+			{"ab", map[string]interface{}{"items": []interface{}{
+				map[string]interface{}{"sku": "a1"},
+				map[string]interface{}{"sku": "x", "price": 20},
+				map[string]interface{}{"sku": "c3"},
+			}}, true, nil},
+			{"ba", map[string]interface{}{"items": []interface{}{
+				map[string]interface{}{"sku": "a1"},
+				map[string]interface{}{"sku": "b2", "price": 10},
+			}}, false, nil},
+			{"bb", map[string]interface{}{"items": []interface{}{
+				map[string]interface{}{"sku": "b2"},
+				map[string]interface{}{"sku": "b2", "price": 20},
+			}}, false, nil},
+			{"bc", map[string]interface{}{"items": []interface{}{
+				map[string]interface{}{"sku": "x"},
+				map[string]interface{}{"sku": "b2", "price": 20},
+			}}, false, nil},
+		},
+	},
+	// wildcard in path: any element has to satisfy the comparator
+	{
+		symbol: "LB",
+		query: map[string]interface{}{
+			"items.*.price": map[string]interface{}{"$gte": 100},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"items": []interface{}{
+				map[string]interface{}{"price": 10},
+				map[string]interface{}{"price": 100},
+			}}, true, nil},
+			{"ab", map[string]interface{}{"items": []interface{}{
+				map[string]interface{}{"price": 150.5},
+			}}, true, nil},
+			{"ba", map[string]interface{}{"items": []interface{}{
+				map[string]interface{}{"price": 10},
+				map[string]interface{}{"price": 99},
+			}}, false, nil},
+			{"bb", map[string]interface{}{"items": []interface{}{}}, false, nil},
+			{"bc", map[string]interface{}{}, false, nil},
+			// element without the key is skipped
+			{"bd", map[string]interface{}{"items": []interface{}{
+				map[string]interface{}{"sku": "a"},
+			}}, false, nil},
+			// type errors are reported
+			{"ca", map[string]interface{}{"items": []interface{}{
+				map[string]interface{}{"price": "10"},
+			}}, false, ErrTypeMismatch},
+		},
+	},
+	// wildcard with scalar, list and multiple comparators
+	{
+		symbol: "LC",
+		query: map[string]interface{}{
+			"items.*.sku":  "x",
+			"items.*.tags": map[string]interface{}{"$contains": "sale"},
+			"items.*.qty":  map[string]interface{}{"$gt": 1, "$lt": 5},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"items": []interface{}{
+				map[string]interface{}{"sku": "x", "tags": []interface{}{"new"}, "qty": 1},
+				map[string]interface{}{"sku": "y", "tags": []interface{}{"sale"}, "qty": 3},
+			}}, true, nil},
+			// comparators on one level are matched independently
+			{"ab", map[string]interface{}{"items": []interface{}{
+				map[string]interface{}{"sku": "x", "tags": []interface{}{"sale"}, "qty": 0},
+				map[string]interface{}{"sku": "y", "tags": []interface{}{}, "qty": 10},
+			}}, true, nil},
+			{"ba", map[string]interface{}{"items": []interface{}{
+				map[string]interface{}{"sku": "y", "tags": []interface{}{"sale"}, "qty": 3},
+			}}, false, nil},
+			{"bb", map[string]interface{}{"items": []interface{}{
+				map[string]interface{}{"sku": "x", "tags": []interface{}{"sale"}, "qty": 5},
+			}}, false, nil},
+		},
+	},
+	// negation is applied to the result for all elements
+	{
+		symbol: "LD",
+		query: map[string]interface{}{
+			"items.*.sku": map[string]interface{}{"!$is": "x"},
+			"tags.*":      map[string]interface{}{"$not": []interface{}{"hidden", "draft"}},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"items": []interface{}{map[string]interface{}{"sku": "y"}}, "tags": []interface{}{"a"}}, true, nil},
+			{"ab", map[string]interface{}{}, true, nil},
+			{"ba", map[string]interface{}{"items": []interface{}{map[string]interface{}{"sku": "y"}, map[string]interface{}{"sku": "x"}}}, false, nil},
+			{"bb", map[string]interface{}{"tags": []interface{}{"a", "draft"}}, false, nil},
+		},
+	},
+	// $exists with wildcard
+	{
+		symbol: "LE",
+		query: map[string]interface{}{
+			"items.*.discount": map[string]interface{}{"$exists": true},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"items": []interface{}{map[string]interface{}{}, map[string]interface{}{"discount": nil}}}, true, nil},
+			{"ba", map[string]interface{}{"items": []interface{}{map[string]interface{}{}}}, false, nil},
+			{"bb", map[string]interface{}{}, false, nil},
+		},
+	},
+	// nested wildcards
+	{
+		symbol: "LF",
+		query: map[string]interface{}{
+			"orders.*.items.*.sku": "x",
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"orders": []interface{}{
+				map[string]interface{}{"items": []interface{}{map[string]interface{}{"sku": "a"}}},
+				map[string]interface{}{"items": []interface{}{map[string]interface{}{"sku": "b"}, map[string]interface{}{"sku": "x"}}},
+			}}, true, nil},
+			{"ba", map[string]interface{}{"orders": []interface{}{
+				map[string]interface{}{"items": []interface{}{map[string]interface{}{"sku": "a"}}},
+			}}, false, nil},
+		},
+	},
 
-		// TODO: $contains, $and $contains
-		// TODO: full structure tests
-	}
+	// regular expressions
+	{
+		symbol: "MA",
+		query: map[string]interface{}{
+			"a": map[string]interface{}{"$regex": "^ab+c$"},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": "abc"}, true, nil},
+			{"ab", map[string]interface{}{"a": "abbbc"}, true, nil},
+			{"ba", map[string]interface{}{"a": "ac"}, false, nil},
+			{"bb", map[string]interface{}{"a": "ABC"}, false, nil},
+			{"bc", map[string]interface{}{"a": nil}, false, nil},
+			{"bd", map[string]interface{}{}, false, nil},
+			{"ca", map[string]interface{}{"a": 101}, false, ErrTypeMismatch},
+			{"cb", map[string]interface{}{"a": []interface{}{"abc"}}, false, ErrTypeMismatch},
+		},
+	},
+	// flags
+	{
+		symbol: "MB",
+		query: map[string]interface{}{
+			"a": map[string]interface{}{"$matches": []interface{}{"^b.c$", "im"}},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": "BXC"}, true, nil},
+			{"ab", map[string]interface{}{"a": "a\nbxc\nd"}, true, nil},
+			{"ba", map[string]interface{}{"a": "b\nc"}, false, nil},
+		},
+	},
+	// negation and wildcard
+	{
+		symbol: "MC",
+		query: map[string]interface{}{
+			"tags.*": map[string]interface{}{"!$regex": "^tmp-"},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"tags": []interface{}{"a", "b"}}, true, nil},
+			{"ab", map[string]interface{}{}, true, nil},
+			{"ba", map[string]interface{}{"tags": []interface{}{"a", "tmp-1"}}, false, nil},
+		},
+	},
+	// invalid expectation
+	{
+		symbol: "MD",
+		query: map[string]interface{}{
+			"a": map[string]interface{}{"$regex": "a("},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": "a"}, false, ErrInvalidPattern},
+		},
+	},
+	{
+		symbol: "ME",
+		query: map[string]interface{}{
+			"a": map[string]interface{}{"$regex": []interface{}{"a", "x"}},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": "a"}, false, ErrInvalidPattern},
+		},
+	},
+	{
+		symbol: "MF",
+		query: map[string]interface{}{
+			"a": map[string]interface{}{"$regex": 1},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": "a"}, false, ErrUnknownExpectedType},
+		},
+	},
 
-	for _, tDef := range tests {
-		DEBUG = tDef.debug
+	// string comparators
+	{
+		symbol: "NA",
+		query: map[string]interface{}{
+			"host": map[string]interface{}{"$startsWith": "api.", "$endsWith": ".example.com"},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"host": "api.example.com"}, true, nil},
+			{"ab", map[string]interface{}{"host": "api.eu.example.com"}, true, nil},
+			{"ba", map[string]interface{}{"host": "www.example.com"}, false, nil},
+			{"bb", map[string]interface{}{"host": "api.example.org"}, false, nil},
+			{"bc", map[string]interface{}{"host": "API.example.com"}, false, nil},
+			{"ca", map[string]interface{}{"host": nil}, false, ErrTypeMismatch},
+			{"cb", map[string]interface{}{}, false, ErrTypeMismatch},
+			{"cc", map[string]interface{}{"host": 101}, false, ErrTypeMismatch},
+		},
+	},
+	{
+		symbol: "NB",
+		query: map[string]interface{}{
+			"path": map[string]interface{}{"!$startsWith": "/admin"},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"path": "/api/v1"}, true, nil},
+			{"ba", map[string]interface{}{"path": "/admin/users"}, false, nil},
+			{"ca", map[string]interface{}{"path": []interface{}{"/admin"}}, false, ErrTypeMismatch},
+		},
+	},
+	// case insensitive equality (Unicode case folding)
+	{
+		symbol: "NC",
+		query: map[string]interface{}{
+			"a": map[string]interface{}{"$iis": "Straße"},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": "Straße"}, true, nil},
+			{"ab", map[string]interface{}{"a": "STRAßE"}, true, nil},
+			{"ac", map[string]interface{}{"a": "straße"}, true, nil},
+			{"ba", map[string]interface{}{"a": "strasse"}, false, nil},
+			{"ca", map[string]interface{}{"a": nil}, false, ErrTypeMismatch},
+		},
+	},
+	{
+		symbol: "ND",
+		query: map[string]interface{}{
+			"a": map[string]interface{}{"$endsWith": 1},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": "1"}, false, ErrUnknownExpectedType},
+		},
+	},
+
+	// ordering of strings and timestamps
+	{
+		symbol: "OA",
+		query: map[string]interface{}{
+			"name": map[string]interface{}{"$gte": "m"},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"name": "m"}, true, nil},
+			{"ab", map[string]interface{}{"name": "zoe"}, true, nil},
+			{"ba", map[string]interface{}{"name": "adam"}, false, nil},
+			// byte-wise, upper case letters go first
+			{"bb", map[string]interface{}{"name": "Zoe"}, false, nil},
+			{"ca", map[string]interface{}{"name": 101}, false, ErrTypeMismatch},
+			{"cb", map[string]interface{}{}, false, ErrTypeMismatch},
+		},
+	},
+	{
+		symbol: "OB",
+		query: map[string]interface{}{
+			"created": map[string]interface{}{"$lt": "2020-01-02T00:00:00Z"},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"created": "2020-01-01T23:59:59.999Z"}, true, nil},
+			// chronological, not lexicographic
+			{"ab", map[string]interface{}{"created": "2020-01-02T01:00:00+02:00"}, true, nil},
+			{"ac", map[string]interface{}{"created": time.Date(2019, 12, 31, 0, 0, 0, 0, time.UTC)}, true, nil},
+			{"ba", map[string]interface{}{"created": "2020-01-02T00:00:00Z"}, false, nil},
+			{"bb", map[string]interface{}{"created": "2020-01-02T00:00:00.000000001Z"}, false, nil},
+			{"bc", map[string]interface{}{"created": "2020-01-01T23:00:00-02:00"}, false, nil},
+			// not a timestamp -> lexicographic
+			{"ca", map[string]interface{}{"created": "2020-01-01"}, true, nil},
+		},
+	},
+	{
+		symbol: "OC",
+		query: map[string]interface{}{
+			"created": map[string]interface{}{"$gt": time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"created": "2020-01-02T00:00:01Z"}, true, nil},
+			{"ab", map[string]interface{}{"created": time.Date(2020, 1, 2, 0, 0, 0, 1, time.UTC)}, true, nil},
+			{"ba", map[string]interface{}{"created": "2020-01-01T10:00:00Z"}, false, nil},
+			{"ca", map[string]interface{}{"created": "yesterday"}, false, ErrTypeMismatch},
+			{"cb", map[string]interface{}{"created": 1577923200}, false, ErrTypeMismatch},
+		},
+	},
+	{
+		symbol: "OD",
+		query: map[string]interface{}{
+			"created": map[string]interface{}{"$is": time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"created": time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)}, true, nil},
+			// compared in JSON form, as time.Time in data is
+			{"ab", map[string]interface{}{"created": "2020-01-02T00:00:00Z"}, true, nil},
+			{"ba", map[string]interface{}{"created": time.Date(2020, 1, 2, 0, 0, 1, 0, time.UTC)}, false, nil},
+			{"bb", map[string]interface{}{}, false, nil},
+		},
+	},
+	{
+		symbol: "OE",
+		query: map[string]interface{}{
+			"created": map[string]interface{}{"$in": []interface{}{time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), "x"}},
+			"updated": map[string]interface{}{"$not": time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC)},
+		},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"created": time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), "updated": time.Date(2020, 1, 4, 0, 0, 0, 0, time.UTC)}, true, nil},
+			{"ba", map[string]interface{}{"created": time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), "updated": time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC)}, false, nil},
+			{"bb", map[string]interface{}{"created": time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}, false, nil},
+		},
+	},
+
+	// TODO: full structure tests
+}
+
+func TestDoesMatch(t *testing.T) {
+	for _, tDef := range doesMatchTests {
 		for _, tCase := range tDef.tests {
 			result, err := DoesMatch(tDef.query, tCase.data)
 
-			if (err != nil) || (tCase.err != nil) {
-				var (
-					expectedString string
-					gotString      string
-				)
-				if tCase.err != nil {
-					expectedString = tCase.err.Error()
-				}
-				if err != nil {
-					gotString = err.Error()
-				}
-				if expectedString != gotString {
-					t.Errorf("[%s|%s] Mismatch on error => expected: %#+v, have: %#+v", tDef.symbol, tCase.symbol, tCase.err, err)
-				}
+			if !sameError(tCase.err, err) {
+				t.Errorf("[%s|%s] Mismatch on error => expected: %#+v, have: %#+v", tDef.symbol, tCase.symbol, tCase.err, err)
 			}
 
 			if result != tCase.expected {
@@ -600,3 +1222,11 @@ func TestDoesMatch(t *testing.T) {
 		}
 	}
 }
+
+// sameError checks if have is of the expected kind (nil expects no error).
+func sameError(expected, have error) bool {
+	if expected == nil {
+		return have == nil
+	}
+	return errors.Is(have, expected)
+}
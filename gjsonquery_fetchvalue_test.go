@@ -51,6 +51,64 @@ func TestFetchValue(t *testing.T) {
 				{"ad", "l1_a.l2_b", nil, false},
 			},
 		},
+		// lists
+		{
+			symbol: "CA",
+			data: map[string]interface{}{
+				"items": []interface{}{
+					map[string]interface{}{"sku": "a1", "price": 10},
+					map[string]interface{}{"sku": "b2", "price": 20},
+					map[string]interface{}{"sku": "c3"},
+				},
+				"matrix": []interface{}{[]interface{}{1, 2}, []interface{}{3}},
+				"obj":    map[string]interface{}{"0": "zero", "x": "ex"},
+			},
+			tests: []subTestCase{
+				{"aa", "items.0.sku", "a1", true},
+				{"ab", "items.2.sku", "c3", true},
+				{"ac", "items.-1.sku", "c3", true},
+				{"ad", "items.-3.price", 10, true},
+				{"ae", "matrix.1.0", 3, true},
+				{"af", "obj.0", "zero", true},
+				// out of range / not an index
+				{"ba", "items.3.sku", nil, false},
+				{"bb", "items.-4.sku", nil, false},
+				{"bc", "items.sku", nil, false},
+				{"bd", "items.2.price", nil, false},
+				{"be", "obj.x.0", nil, false},
+				// wildcard
+				{"ca", "items.*.sku", []interface{}{"a1", "b2", "c3"}, true},
+				{"cb", "items.*.price", []interface{}{10, 20}, true},
+				{"cc", "matrix.*.*", []interface{}{1, 2, 3}, true},
+				{"cd", "obj.*", []interface{}{"zero", "ex"}, true},
+				{"ce", "items.*.weight", []interface{}(nil), false},
+				{"cf", "missing.*", []interface{}(nil), false},
+				{"cg", "items.*", []interface{}{
+					map[string]interface{}{"sku": "a1", "price": 10},
+					map[string]interface{}{"sku": "b2", "price": 20},
+					map[string]interface{}{"sku": "c3"},
+				}, true},
+			},
+		},
+		// escaping
+		{
+			symbol: "DA",
+			data: map[string]interface{}{
+				"l1_e.l2_a": "502",
+				"labels":    map[string]interface{}{"k8s.io/name": "api", "*": "star", "a\\b": "slash"},
+				"list":      []interface{}{"x"},
+			},
+			tests: []subTestCase{
+				{"aa", `l1_e\.l2_a`, "502", true},
+				{"ab", `labels.k8s\.io/name`, "api", true},
+				{"ac", `labels.\*`, "star", true},
+				{"ad", `labels.a\\b`, "slash", true},
+				{"ae", `labels.*`, []interface{}{"star", "slash", "api"}, true},
+				{"ba", `l1_e.l2_a`, nil, false},
+				{"bb", `list.\0`, nil, false},
+				{"bc", `list.0`, "x", true},
+			},
+		},
 	}
 
 	for _, tDef := range tests {
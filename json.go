@@ -0,0 +1,293 @@
+package gjsonquery
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+)
+
+// errNotStreamable makes decodeSelected fall back to json.Unmarshal.
+var errNotStreamable = errors.New("not streamable")
+
+// DoesMatchJSON reports whether JSON object in data matches the query.
+//
+// Only values referenced by the query are decoded, the rest of the document is skipped.
+// Result is the same as of DoesMatch with data decoded by encoding/json (numbers are float64).
+// Malformed JSON is reported with the same error as returned by json.Unmarshal.
+func DoesMatchJSON(query interface{}, data []byte) (bool, error) {
+	return defaultMatcher.DoesMatchJSON(query, data)
+}
+
+// DoesMatchJSON reports whether JSON object in data matches the query, see DoesMatchJSON.
+func (m *Matcher) DoesMatchJSON(query interface{}, data []byte) (bool, error) {
+	decoded, err := decodeSelected(m.opts, newSelector(m.opts, query), data)
+	if err != nil {
+		return false, err
+	}
+	return m.DoesMatch(query, decoded)
+}
+
+// MatchJSON reports whether JSON object in data matches the compiled query, see DoesMatchJSON.
+func (q *Query) MatchJSON(data []byte) (bool, error) {
+	decoded, err := decodeSelected(q.opts, q.selector, data)
+	if err != nil {
+		return false, err
+	}
+	return q.Match(decoded)
+}
+
+// selector is a tree of paths referenced by a query.
+type selector struct {
+	// all is set when whole value is needed
+	all      bool
+	keys     map[string]*selector
+	indexes  map[int]*selector
+	wildcard *selector
+	// negative is set when any of indexes counts from the end of the list
+	negative bool
+}
+
+// newSelector collects paths of all columns in query.
+// Query is walked the same way as matcherAnd does, malformed parts are ignored as they are reported by matching.
+func newSelector(o *options, query interface{}) *selector {
+	s := &selector{}
+	s.addQuery(o, query)
+	return s
+}
+
+func (s *selector) addQuery(o *options, query interface{}) {
+	switch v := query.(type) {
+	case map[string]interface{}:
+		for column, expectation := range v {
+			s.addColumn(o, column, expectation)
+		}
+	case []interface{}:
+		for _, item := range v {
+			s.addQuery(o, item)
+		}
+	case Expr:
+		s.addQuery(o, v.Query())
+	}
+}
+
+func (s *selector) addColumn(o *options, column string, expectation interface{}) {
+	column = strings.TrimLeft(column, "!")
+	switch {
+	case column == "$and" || column == "$or" || column == "$not":
+		s.addQuery(o, expectation)
+	case strings.HasPrefix(column, "$"):
+		// comparator applied directly to the data
+		s.all = true
+	default:
+		if path, err := splitColumn(o, column); err == nil {
+			s.addPath(path)
+		}
+	}
+}
+
+func (s *selector) addPath(path []pathElement) {
+	current := s
+	for _, el := range path {
+		current = current.child(el)
+	}
+	current.all = true
+}
+
+func (s *selector) child(el pathElement) *selector {
+	if el.wildcard {
+		if s.wildcard == nil {
+			s.wildcard = &selector{}
+		}
+		return s.wildcard
+	}
+
+	if s.keys == nil {
+		s.keys = make(map[string]*selector)
+	}
+	next, ok := s.keys[el.key]
+	if !ok {
+		next = &selector{}
+		s.keys[el.key] = next
+	}
+	// index elements are keys for maps and indexes for lists, both share the same subtree
+	if el.isIndex {
+		if s.indexes == nil {
+			s.indexes = make(map[int]*selector)
+		}
+		s.indexes[el.index] = next
+		s.negative = s.negative || el.index < 0
+	}
+	return next
+}
+
+// forKey returns selectors of a map value.
+func forKey(o *options, selectors []*selector, key string) []*selector {
+	var out []*selector
+	for _, s := range selectors {
+		if s.wildcard != nil {
+			out = append(out, s.wildcard)
+		}
+		if next, ok := s.keys[key]; ok {
+			out = append(out, next)
+		}
+		if o.caseInsensitiveKeys {
+			// all keys matching ignoring case are kept, fetchElement picks the right one
+			for k, next := range s.keys {
+				if k != key && strings.EqualFold(k, key) {
+					out = append(out, next)
+				}
+			}
+		}
+	}
+	return out
+}
+
+// forIndex returns selectors of a list element.
+// Length of the list is not known while decoding so elements are kept for all negative indexes.
+func forIndex(selectors []*selector, i int) []*selector {
+	var out []*selector
+	for _, s := range selectors {
+		if s.wildcard != nil {
+			out = append(out, s.wildcard)
+		}
+		if next, ok := s.indexes[i]; ok {
+			out = append(out, next)
+		}
+		if s.negative {
+			for index, next := range s.indexes {
+				if index < 0 {
+					out = append(out, next)
+				}
+			}
+		}
+	}
+	return out
+}
+
+// decodeSelected decodes JSON object keeping only values selected by s.
+// Lists keep their length, elements which are not selected are nil.
+// Documents which can not be streamed are decoded by json.Unmarshal so errors are the same as reported by it.
+func decodeSelected(o *options, s *selector, data []byte) (map[string]interface{}, error) {
+	if !s.all {
+		if out, err := decodeStream(o, s, data); err == nil {
+			return out, nil
+		}
+	}
+
+	var out map[string]interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func decodeStream(o *options, s *selector, data []byte) (map[string]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]interface{}
+	switch token {
+	case json.Delim('{'):
+		if out, err = decodeObject(o, dec, []*selector{s}); err != nil {
+			return nil, err
+		}
+	case nil:
+		// null decodes into nil map
+	default:
+		return nil, errNotStreamable
+	}
+
+	// nothing but whitespace is allowed after the object
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errNotStreamable
+	}
+	return out, nil
+}
+
+// decodeValue decodes next value from dec, selectors must not be empty.
+func decodeValue(o *options, dec *json.Decoder, selectors []*selector) (interface{}, error) {
+	for _, s := range selectors {
+		if s.all {
+			var v interface{}
+			err := dec.Decode(&v)
+			return v, err
+		}
+	}
+
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case json.Delim('{'):
+		return decodeObject(o, dec, selectors)
+	case json.Delim('['):
+		return decodeList(o, dec, selectors)
+	}
+	// scalar
+	return token, nil
+}
+
+// decodeObject decodes remaining part of the object after opening delimiter.
+func decodeObject(o *options, dec *json.Decoder, selectors []*selector) (map[string]interface{}, error) {
+	out := make(map[string]interface{})
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := token.(string)
+
+		next := forKey(o, selectors, key)
+		if len(next) == 0 {
+			if err := skipValue(dec); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if out[key], err = decodeValue(o, dec, next); err != nil {
+			return nil, err
+		}
+	}
+	// closing delimiter
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// decodeList decodes remaining part of the list after opening delimiter.
+func decodeList(o *options, dec *json.Decoder, selectors []*selector) ([]interface{}, error) {
+	out := make([]interface{}, 0)
+	for i := 0; dec.More(); i++ {
+		next := forIndex(selectors, i)
+		if len(next) == 0 {
+			if err := skipValue(dec); err != nil {
+				return nil, err
+			}
+			out = append(out, nil)
+			continue
+		}
+		item, err := decodeValue(o, dec, next)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, item)
+	}
+	// closing delimiter
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// skipValue consumes next value from dec without decoding it.
+func skipValue(dec *json.Decoder) error {
+	var raw json.RawMessage
+	return dec.Decode(&raw)
+}
@@ -0,0 +1,155 @@
+package gjsonquery_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	. "github.com/szpakas/gjsonquery"
+)
+
+// sameResult compares results of two evaluations, errors are compared by message.
+func sameResult(expected bool, expectedErr error, have bool, haveErr error) bool {
+	return expected == have && fmt.Sprint(expectedErr) == fmt.Sprint(haveErr)
+}
+
+func TestDoesMatchJSONSameAsDoesMatch(t *testing.T) {
+	for _, tDef := range doesMatchTests {
+		q, compileErr := Compile(tDef.query)
+
+		for _, tCase := range tDef.tests {
+			raw, err := json.Marshal(tCase.data)
+			if err != nil {
+				continue
+			}
+			var decoded map[string]interface{}
+			if err := json.Unmarshal(raw, &decoded); err != nil {
+				t.Fatalf("[%s|%s] Unmarshal failed: %s", tDef.symbol, tCase.symbol, err)
+			}
+
+			expected, expectedErr := DoesMatch(tDef.query, decoded)
+			have, haveErr := DoesMatchJSON(tDef.query, raw)
+			if !sameResult(expected, expectedErr, have, haveErr) {
+				t.Errorf("[%s|%s] Mismatch => expected: %t (err: %v), have: %t (err: %v)", tDef.symbol, tCase.symbol, expected, expectedErr, have, haveErr)
+			}
+
+			if compileErr != nil {
+				continue
+			}
+			expected, expectedErr = q.Match(decoded)
+			have, haveErr = q.MatchJSON(raw)
+			if !sameResult(expected, expectedErr, have, haveErr) {
+				t.Errorf("[%s|%s] Mismatch on compiled => expected: %t (err: %v), have: %t (err: %v)", tDef.symbol, tCase.symbol, expected, expectedErr, have, haveErr)
+			}
+		}
+	}
+}
+
+func TestDoesMatchJSON(t *testing.T) {
+	var tests = []struct {
+		symbol   string
+		query    interface{}
+		data     string
+		expected bool
+	}{
+		{"aa", map[string]interface{}{"a": 1}, `{"a": 1, "b": {"c": [1, 2, {"d": "x"}]}}`, true},
+		{"ab", map[string]interface{}{"b.c.2.d": "x"}, `{"a": 1, "b": {"c": [1, 2, {"d": "x"}]}}`, true},
+		{"ac", map[string]interface{}{"b.c.-1.d": "x"}, `{"a": 1, "b": {"c": [1, 2, {"d": "x"}]}}`, true},
+		{"ad", map[string]interface{}{"b.c.-3": 1}, `{"a": 1, "b": {"c": [1, 2, {"d": "x"}]}}`, true},
+		{"ae", map[string]interface{}{"b.c.*.d": "x"}, `{"a": 1, "b": {"c": [1, 2, {"d": "x"}]}}`, true},
+		{"af", map[string]interface{}{"b": map[string]interface{}{"$exists": true}}, `{"a": 1, "b": null}`, true},
+		{"ag", map[string]interface{}{"b.c": []interface{}{1.0}}, `{"b": {"c": 1}}`, true},
+		// duplicate keys -> last one wins
+		{"ah", map[string]interface{}{"a": 2}, `{"a": 1, "a": 2}`, true},
+		{"ai", map[string]interface{}{"a.x": 1}, `{"a": {"x": 1}, "a": {"y": 1}}`, false},
+		// whole document
+		{"ba", map[string]interface{}{"$contains": "a"}, `{"a": 1}`, false},
+		{"bb", map[string]interface{}{"a": map[string]interface{}{"$exists": false}}, `null`, true},
+		{"bc", map[string]interface{}{"!b.c.0.d": map[string]interface{}{"$exists": true}}, `{"b": {"c": [{"d": 1}]}}`, false},
+		{"bd", []interface{}{map[string]interface{}{"$or": map[string]interface{}{"x": 1, "y": 2}}}, `{"x": 0, "y": 2}`, true},
+	}
+
+	for _, tc := range tests {
+		q, err := Compile(tc.query)
+		if err != nil {
+			t.Fatalf("[%s] Compile failed: %s", tc.symbol, err)
+		}
+		var decoded map[string]interface{}
+		if err := json.Unmarshal([]byte(tc.data), &decoded); err != nil {
+			t.Fatalf("[%s] Unmarshal failed: %s", tc.symbol, err)
+		}
+
+		expected, expectedErr := DoesMatch(tc.query, decoded)
+		if expected != tc.expected {
+			t.Errorf("[%s] Mismatch on DoesMatch => expected: %t, have: %t (err: %v)", tc.symbol, tc.expected, expected, expectedErr)
+		}
+		for name, match := range map[string]func() (bool, error){
+			"DoesMatchJSON":   func() (bool, error) { return DoesMatchJSON(tc.query, []byte(tc.data)) },
+			"Query.MatchJSON": func() (bool, error) { return q.MatchJSON([]byte(tc.data)) },
+		} {
+			have, haveErr := match()
+			if !sameResult(expected, expectedErr, have, haveErr) {
+				t.Errorf("[%s|%s] Mismatch => expected: %t (err: %v), have: %t (err: %v)", tc.symbol, name, expected, expectedErr, have, haveErr)
+			}
+		}
+	}
+}
+
+func TestDoesMatchJSONCaseInsensitiveKeys(t *testing.T) {
+	m := NewMatcher(WithCaseInsensitiveKeys(true))
+	query := map[string]interface{}{"user.name": "b"}
+	data := `{"USER": {"Name": "a", "name": "b"}, "other": [1, 2, 3]}`
+
+	matched, err := m.DoesMatchJSON(query, []byte(data))
+	if err != nil || !matched {
+		t.Errorf("Mismatch => expected: true, have: %t (err: %v)", matched, err)
+	}
+}
+
+func TestDoesMatchJSONInvalid(t *testing.T) {
+	query := map[string]interface{}{"a": 1}
+	for _, data := range []string{``, `{"a": 1`, `{"a": 1}}`, `{"a": 1} {}`, `[1]`, `"a"`, `{"b": [1, }`, `{"a": 1, "b": tru}`} {
+		var decoded map[string]interface{}
+		expectedErr := json.Unmarshal([]byte(data), &decoded)
+		if expectedErr == nil {
+			t.Fatalf("[%s] Unmarshal should fail", data)
+		}
+
+		matched, err := DoesMatchJSON(query, []byte(data))
+		if matched || fmt.Sprint(err) != expectedErr.Error() {
+			t.Errorf("[%s] Mismatch on error => expected: %v, have: %t (err: %v)", data, expectedErr, matched, err)
+		}
+	}
+}
+
+func TestDecodeSelected(t *testing.T) {
+	data := []byte(`{"a": 1, "b": {"c": [1, 2, {"d": "x", "e": "y"}], "f": true}, "g": {"h": [1, {"i": 2}]}}`)
+
+	var tests = []struct {
+		symbol   string
+		query    interface{}
+		expected map[string]interface{}
+	}{
+		{"aa", map[string]interface{}{"a": 1}, map[string]interface{}{"a": 1.0}},
+		{"ab", map[string]interface{}{"b.c.2.d": "x"}, map[string]interface{}{
+			"b": map[string]interface{}{"c": []interface{}{nil, nil, map[string]interface{}{"d": "x"}}},
+		}},
+		// length of the list is not known up front -> negative index keeps all elements
+		{"ac", map[string]interface{}{"b.c.-1.e": "x", "!g.h.0": 1}, map[string]interface{}{
+			"b": map[string]interface{}{"c": []interface{}{1.0, 2.0, map[string]interface{}{"e": "y"}}},
+			"g": map[string]interface{}{"h": []interface{}{1.0, nil}},
+		}},
+		{"ad", map[string]interface{}{"$or": []interface{}{map[string]interface{}{"g.*.*.i": 1}}}, map[string]interface{}{
+			"g": map[string]interface{}{"h": []interface{}{1.0, map[string]interface{}{"i": 2.0}}},
+		}},
+		{"ae", map[string]interface{}{"x.y": 1}, map[string]interface{}{}},
+	}
+
+	for _, tc := range tests {
+		decoded, err := DecodeSelected(tc.query, data)
+		if err != nil || !reflect.DeepEqual(decoded, tc.expected) {
+			t.Errorf("[%s] Mismatch\nexpected => %#+v\n    have => %#+v (err: %v)", tc.symbol, tc.expected, decoded, err)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package gjsonquery
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"sort"
+)
+
+// MarshalJSON encodes compiled query in canonical form.
+//
+// Keys are sorted, nested "$and" and double negations are collapsed and comparators are written by their
+// canonical names ("$not" as "!$is" or "!$in", "$matches" as "$regex"). "$is" with scalar and "$in"
+// are written in short form ({"a": 1}, {"a": [1, 2]}). When keys would repeat on one level, list is used instead of object.
+// Query parsed back from the canonical form encodes into the same bytes and has the same meaning.
+// Expectations have to be JSON values (nil, bool, string, integers, float64, json.Number, *big.Int and lists
+// and objects of them), other Go values (e.g. time.Time) are reported with ErrUnknownExpectedType
+// as they would be compared differently once written as JSON.
+func (q *Query) MarshalJSON() ([]byte, error) {
+	if err := canonicalValues(q.root); err != nil {
+		return nil, err
+	}
+	return json.Marshal(canonicalAnd(q.root))
+}
+
+// UnmarshalJSON parses query with the default configuration, see ParseQuery.
+func (q *Query) UnmarshalJSON(data []byte) error {
+	parsed, err := ParseQuery(data)
+	if err != nil {
+		return err
+	}
+	*q = *parsed
+	return nil
+}
+
+// canonicalEntry is a single key of a query object.
+type canonicalEntry struct {
+	key   string
+	value interface{}
+}
+
+// canonicalAnd renders node as a query accepted by matcherAnd (object or list).
+func canonicalAnd(n node) interface{} {
+	return canonicalObject(canonicalEntries(n, false))
+}
+
+// canonicalObject joins entries into an object, or into a sorted list of single key objects if keys repeat.
+func canonicalObject(entries []canonicalEntry) interface{} {
+	object := make(map[string]interface{}, len(entries))
+	for _, e := range entries {
+		if _, ok := object[e.key]; ok {
+			items := make([]interface{}, len(entries))
+			for i, e := range entries {
+				items[i] = map[string]interface{}{e.key: e.value}
+			}
+			return canonicalSorted(items)
+		}
+		object[e.key] = e.value
+	}
+	return object
+}
+
+// canonicalSorted orders list by JSON form of the items.
+func canonicalSorted(items []interface{}) []interface{} {
+	encoded := make([][]byte, len(items))
+	for i, item := range items {
+		// unsupported values are reported by the final json.Marshal
+		encoded[i], _ = json.Marshal(item)
+	}
+	sort.Sort(canonicalList{items: items, encoded: encoded})
+	return items
+}
+
+type canonicalList struct {
+	items   []interface{}
+	encoded [][]byte
+}
+
+func (l canonicalList) Len() int           { return len(l.items) }
+func (l canonicalList) Less(i, j int) bool { return bytes.Compare(l.encoded[i], l.encoded[j]) < 0 }
+func (l canonicalList) Swap(i, j int) {
+	l.items[i], l.items[j] = l.items[j], l.items[i]
+	l.encoded[i], l.encoded[j] = l.encoded[j], l.encoded[i]
+}
+
+// canonicalChecks orders checks of a column by canonical name and JSON form of the expectation.
+type canonicalChecks struct {
+	entries []canonicalEntry
+	encoded [][]byte
+}
+
+func (l canonicalChecks) Len() int { return len(l.entries) }
+func (l canonicalChecks) Less(i, j int) bool {
+	if l.entries[i].key != l.entries[j].key {
+		return l.entries[i].key < l.entries[j].key
+	}
+	return bytes.Compare(l.encoded[i], l.encoded[j]) < 0
+}
+func (l canonicalChecks) Swap(i, j int) {
+	l.entries[i], l.entries[j] = l.entries[j], l.entries[i]
+	l.encoded[i], l.encoded[j] = l.encoded[j], l.encoded[i]
+}
+
+// canonicalEntries renders node as entries of an AND object, negated tells if odd number of negations wraps the node.
+func canonicalEntries(n node, negated bool) []canonicalEntry {
+	switch v := n.(type) {
+	case *notNode:
+		return canonicalEntries(v.child, !negated)
+	case *andNode:
+		if negated {
+			// children without entries always match, so the negation applies to the rest
+			var children []node
+			for _, child := range v.children {
+				if len(canonicalEntries(child, false)) > 0 {
+					children = append(children, child)
+				}
+			}
+			if len(children) == 1 {
+				return canonicalEntries(children[0], true)
+			}
+			return []canonicalEntry{{key: "$not", value: canonicalAnd(v)}}
+		}
+		var entries []canonicalEntry
+		for _, child := range v.children {
+			entries = append(entries, canonicalEntries(child, false)...)
+		}
+		return entries
+	case *orNode:
+		return []canonicalEntry{{key: canonicalNegation(negated) + "$or", value: canonicalOr(v)}}
+	case *comparatorNode:
+		cmp := v.check.cmp
+		cmp.negated = cmp.negated != negated
+		return []canonicalEntry{{key: cmp.String(), value: v.check.expectation}}
+	case *columnNode:
+		entries := canonicalColumn(v)
+		if negated && len(entries) == 0 {
+			// column without checks always matches, its negation never does
+			return []canonicalEntry{{key: "$not", value: map[string]interface{}{}}}
+		}
+		if negated && len(entries) > 1 {
+			// negation applies to all checks together
+			return []canonicalEntry{{key: "$not", value: canonicalObject(entries)}}
+		}
+		for i := range entries {
+			entries[i].key = canonicalNegation(negated) + entries[i].key
+		}
+		return entries
+	}
+	return nil
+}
+
+// canonicalValues reports the first expectation which is not a JSON value.
+func canonicalValues(n node) error {
+	var checks []check
+	column := ""
+	switch v := n.(type) {
+	case *notNode:
+		return canonicalValues(v.child)
+	case *andNode:
+		for _, child := range v.children {
+			if err := canonicalValues(child); err != nil {
+				return err
+			}
+		}
+	case *orNode:
+		for _, child := range v.children {
+			if err := canonicalValues(child); err != nil {
+				return err
+			}
+		}
+	case *comparatorNode:
+		checks = []check{v.check}
+	case *columnNode:
+		checks, column = v.checks, v.column
+	}
+	for _, c := range checks {
+		if !isJSONValue(c.expectation) {
+			return &QueryError{Kind: ErrUnknownExpectedType, Op: "MarshalJSON", Column: column, Comparator: c.name, Value: c.expectation}
+		}
+	}
+	return nil
+}
+
+// isJSONValue reports whether v is written as JSON without changing its meaning.
+// float32 is not, its JSON form is parsed back as a different float64.
+func isJSONValue(v interface{}) bool {
+	switch v := v.(type) {
+	case nil, bool, string, json.Number, *big.Int,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64,
+		float64:
+		return true
+	case []interface{}:
+		for _, e := range v {
+			if !isJSONValue(e) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		for _, e := range v {
+			if !isJSONValue(e) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func canonicalNegation(negated bool) string {
+	if negated {
+		return "!"
+	}
+	return ""
+}
+
+// canonicalOr renders alternatives as an object when every alternative is a single entry with unique key.
+func canonicalOr(n *orNode) interface{} {
+	alternatives := make([][]canonicalEntry, len(n.children))
+	object := make(map[string]interface{}, len(n.children))
+	for i, child := range n.children {
+		alternatives[i] = canonicalEntries(child, false)
+		if object != nil && len(alternatives[i]) == 1 {
+			if _, ok := object[alternatives[i][0].key]; !ok {
+				object[alternatives[i][0].key] = alternatives[i][0].value
+				continue
+			}
+		}
+		object = nil
+	}
+	if object != nil {
+		return object
+	}
+
+	items := make([]interface{}, len(alternatives))
+	for i, entries := range alternatives {
+		items[i] = canonicalObject(entries)
+	}
+	return canonicalSorted(items)
+}
+
+// canonicalColumn renders checks of the column, usually as a single entry.
+// Checks with the same canonical name (e.g. "$not" and "!$is") have to be split into more entries.
+// Checks are grouped in order of canonical names (and expectations), so the result does not depend on evaluation order.
+// Every entry holding a single "$is" with scalar or "$in" is written in short form, so it is grouped the same way when parsed back.
+func canonicalColumn(n *columnNode) []canonicalEntry {
+	checks := make([]canonicalEntry, len(n.checks))
+	encoded := make([][]byte, len(n.checks))
+	for i, c := range n.checks {
+		checks[i] = canonicalEntry{key: c.cmp.String(), value: c.expectation}
+		// unsupported values are reported by the final json.Marshal
+		encoded[i], _ = json.Marshal(c.expectation)
+	}
+	sort.Sort(canonicalChecks{entries: checks, encoded: encoded})
+
+	var (
+		entries []canonicalEntry
+		current map[string]interface{}
+	)
+	for _, c := range checks {
+		name := c.key
+		if _, ok := current[name]; ok || current == nil {
+			current = make(map[string]interface{})
+			entries = append(entries, canonicalEntry{key: n.column, value: current})
+		}
+		current[name] = c.value
+	}
+	for i := range entries {
+		entries[i].value = canonicalShort(entries[i].value.(map[string]interface{}))
+	}
+	return entries
+}
+
+// canonicalShort returns expectation of a single "$is" with scalar or "$in" in place of the comparator map.
+func canonicalShort(checks map[string]interface{}) interface{} {
+	if len(checks) != 1 {
+		return checks
+	}
+	if expectation, ok := checks["$is"]; ok && isScalar(expectation) {
+		return expectation
+	}
+	if expectation, ok := checks["$in"].([]interface{}); ok {
+		return expectation
+	}
+	return checks
+}
@@ -0,0 +1,169 @@
+package gjsonquery_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/szpakas/gjsonquery"
+)
+
+func TestQueryMarshalJSON(t *testing.T) {
+	var tests = []struct {
+		symbol   string
+		query    string
+		expected string
+	}{
+		{"aa", `{"b": 1, "a": "x"}`, `{"a":"x","b":1}`},
+		{"ab", `{"a": {"$is": 1}}`, `{"a":1}`},
+		{"ac", `{"a": {"$in": [1, 2]}}`, `{"a":[1,2]}`},
+		{"ad", `{"a": {"$lt": 65, "$gte": 18}}`, `{"a":{"$gte":18,"$lt":65}}`},
+		{"ae", `{"a": {"$is": [1]}}`, `{"a":{"$is":[1]}}`},
+		// negations
+		{"ba", `{"a": {"!!$is": 1}}`, `{"a":1}`},
+		{"bb", `{"a": {"!!!$gt": 1}}`, `{"a":{"!$gt":1}}`},
+		{"bc", `{"!!a": 1, "!!!b": 2}`, `{"!b":2,"a":1}`},
+		{"bd", `{"a": {"$not": 1}}`, `{"a":{"!$is":1}}`},
+		{"be", `{"a": {"!$not": [1, 2]}}`, `{"a":[1,2]}`},
+		{"bf", `{"$not": {"$not": {"a": 1}}}`, `{"a":1}`},
+		{"bg", `{"!$not": {"a": 1, "b": 2}}`, `{"a":1,"b":2}`},
+		{"bh", `{"!$and": {"a": 1}}`, `{"!a":1}`},
+		{"bi", `{"!$and": {"a": 1, "b": 2}}`, `{"$not":{"a":1,"b":2}}`},
+		{"bj", `{"!$or": {"a": 1}}`, `{"!$or":{"a":1}}`},
+		{"bk", `{"!!$gt": 1, "!$exists": true}`, `{"!$exists":true,"$gt":1}`},
+		{"bl", `{"!a": {}}`, `{"$not":{}}`},
+		{"bm", `{"$not": {"a": {"$gt": 1, "$lt": 3}, "$and": {}}}`, `{"!a":{"$gt":1,"$lt":3}}`},
+		// logical operators
+		{"ca", `{"$and": [{"a": 1}, {"$and": {"b": 2}}]}`, `{"a":1,"b":2}`},
+		{"cb", `[{"a": 1}, {"a": 2}]`, `[{"a":1},{"a":2}]`},
+		{"cc", `[{"a": 2}, {"a": 1}]`, `[{"a":1},{"a":2}]`},
+		{"cd", `{"$or": [{"b": 1}, {"a": 2}]}`, `{"$or":{"a":2,"b":1}}`},
+		{"ce", `{"$or": [{"a": 1}, {"a": 2}]}`, `{"$or":[{"a":1},{"a":2}]}`},
+		{"cf", `{"$or": [{"a": 1, "b": 1}, {"c": 2}]}`, `{"$or":[{"a":1,"b":1},{"c":2}]}`},
+		{"cg", `{"$or": {"$or": {"a": 1}}}`, `{"$or":{"$or":{"a":1}}}`},
+		{"ch", `{}`, `{}`},
+		{"ci", `[{}, []]`, `{}`},
+		// canonical comparator names, clashes on a single column
+		{"da", `{"a": {"$matches": "^x"}}`, `{"a":{"$regex":"^x"}}`},
+		{"db", `{"a": {"$not": 1, "!$is": 2}}`, `[{"a":{"!$is":1}},{"a":{"!$is":2}}]`},
+		{"dc", `{"!a": {"$not": 1, "!$is": 2}}`, `{"$not":[{"a":{"!$is":1}},{"a":{"!$is":2}}]}`},
+		{"dd", `{"a": {"$not": 1, "!$is": 2}, "b": 1}`, `[{"a":{"!$is":1}},{"a":{"!$is":2}},{"b":1}]`},
+		{"de", `{"a": {"$is": 1, "!!$is": 2}}`, `[{"a":1},{"a":2}]`},
+		{"df", `{"a.*": {"$gt": 0, "$is": 1, "!!$is": 2}}`, `[{"a.*":2},{"a.*":{"$gt":0,"$is":1}}]`},
+		{"dg", `{"a": {"$in": [1], "!!$in": [2], "$exists": true}}`, `[{"a":[2]},{"a":{"$exists":true,"$in":[1]}}]`},
+	}
+
+	for _, tc := range tests {
+		q, err := ParseQueryString(tc.query)
+		if err != nil {
+			t.Fatalf("[%s] ParseQueryString failed: %s", tc.symbol, err)
+		}
+		have, err := json.Marshal(q)
+		if err != nil || string(have) != tc.expected {
+			t.Errorf("[%s] Mismatch\nexpected => %s\n    have => %s (err: %v)", tc.symbol, tc.expected, have, err)
+		}
+	}
+}
+
+// roundTripTests complement doesMatchTests with queries which are not in the canonical form.
+var roundTripTests = []doesMatchTestCase{
+	{
+		symbol: "AA",
+		query:  map[string]interface{}{"!a": map[string]interface{}{}},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 1}, false, nil},
+			{"ab", map[string]interface{}{}, false, nil},
+		},
+	},
+	{
+		symbol: "AB",
+		query:  map[string]interface{}{"$not": map[string]interface{}{"a": map[string]interface{}{}}, "b": 1},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 1, "b": 1}, false, nil},
+		},
+	},
+	{
+		symbol: "AC",
+		query:  map[string]interface{}{"$or": []interface{}{map[string]interface{}{"!a": map[string]interface{}{}}, map[string]interface{}{"b": 1}}},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"b": 1}, true, nil},
+			{"ab", map[string]interface{}{"b": 2}, false, nil},
+		},
+	},
+	{
+		symbol: "AD",
+		query:  map[string]interface{}{"a": map[string]interface{}{"$is": 1, "!!$is": 2}},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"a": 1}, false, nil},
+		},
+	},
+	{
+		symbol: "AE",
+		query:  map[string]interface{}{"l.*": map[string]interface{}{"$in": []interface{}{1}, "!!$in": []interface{}{2}, "$gt": 0}},
+		tests: []doesMatchSubTestCase{
+			{"aa", map[string]interface{}{"l": []interface{}{1, 2}}, true, nil},
+			{"ab", map[string]interface{}{"l": []interface{}{1}}, false, nil},
+		},
+	},
+}
+
+func TestQueryMarshalJSONRoundTrip(t *testing.T) {
+	for _, tDef := range append(append([]doesMatchTestCase(nil), doesMatchTests...), roundTripTests...) {
+		q, err := Compile(tDef.query)
+		if err != nil {
+			continue
+		}
+		encoded, err := json.Marshal(q)
+		if err != nil {
+			// expectations which are not JSON values, see TestQueryMarshalJSONNotJSONValue
+			if !errors.Is(err, ErrUnknownExpectedType) {
+				t.Errorf("[%s] Marshal failed: %s", tDef.symbol, err)
+			}
+			continue
+		}
+
+		var parsed Query
+		if err := json.Unmarshal(encoded, &parsed); err != nil {
+			t.Errorf("[%s] Unmarshal of %s failed: %s", tDef.symbol, encoded, err)
+			continue
+		}
+		reencoded, err := json.Marshal(&parsed)
+		if err != nil || string(reencoded) != string(encoded) {
+			t.Errorf("[%s] Mismatch on round trip\nexpected => %s\n    have => %s (err: %v)", tDef.symbol, encoded, reencoded, err)
+		}
+
+		// canonical form has the same meaning
+		for _, tCase := range tDef.tests {
+			expected, expectedErr := q.Match(tCase.data)
+			have, haveErr := parsed.Match(tCase.data)
+			if expected != have || (expectedErr == nil) != (haveErr == nil) {
+				t.Errorf("[%s|%s] Mismatch => expected: %t (err: %v), have: %t (err: %v)", tDef.symbol, tCase.symbol, expected, expectedErr, have, haveErr)
+			}
+		}
+	}
+}
+
+func TestQueryMarshalJSONNotJSONValue(t *testing.T) {
+	var tests = []struct {
+		symbol string
+		query  interface{}
+	}{
+		{"aa", map[string]interface{}{"a": map[string]interface{}{"$gt": time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)}}},
+		{"ab", map[string]interface{}{"a": map[string]interface{}{"$in": []interface{}{1, time.Second}}}},
+		{"ac", map[string]interface{}{"$or": map[string]interface{}{"a": float32(0.1)}}},
+		{"ad", map[string]interface{}{"$is": map[string]interface{}{"b": []string{"x"}}}},
+	}
+
+	for _, tc := range tests {
+		q, err := Compile(tc.query)
+		if err != nil {
+			t.Fatalf("[%s] Compile failed: %s", tc.symbol, err)
+		}
+		_, err = json.Marshal(q)
+		var qErr *QueryError
+		if !errors.Is(err, ErrUnknownExpectedType) || !errors.As(err, &qErr) || qErr.Op != "MarshalJSON" {
+			t.Errorf("[%s] Expected MarshalJSON error of kind ErrUnknownExpectedType, have: %v", tc.symbol, err)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package gjsonquery
+
+// Matcher evaluates queries with its own configuration.
+// Matcher is immutable and safe for concurrent use.
+type Matcher struct {
+	opts *options
+}
+
+// defaultMatcher backs the package level functions.
+var defaultMatcher = &Matcher{opts: defaultOptions}
+
+// NewMatcher creates Matcher configured with opts.
+func NewMatcher(opts ...Option) *Matcher {
+	return &Matcher{opts: newOptions(opts)}
+}
+
+// DoesMatch reports whether data matches the query.
+func (m *Matcher) DoesMatch(query interface{}, data map[string]interface{}) (bool, error) {
+	// first level match is always AND
+	return matcherAnd(m.opts, query, data)
+}
+
+// Compile validates query and converts it into a Query using Matcher configuration.
+func (m *Matcher) Compile(query interface{}) (*Query, error) {
+	return compile(m.opts, query)
+}
+
+// Validate checks the whole query up front, see Validate.
+func (m *Matcher) Validate(query interface{}) error {
+	_, err := m.Compile(query)
+	return err
+}
+
+// JoinPath builds column path from literal keys in the path syntax of the Matcher, see JoinPath.
+func (m *Matcher) JoinPath(keys ...string) string {
+	return joinPath(m.opts, keys)
+}
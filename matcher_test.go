@@ -0,0 +1,197 @@
+package gjsonquery_test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/szpakas/gjsonquery"
+)
+
+func TestMatcherDefault(t *testing.T) {
+	m := NewMatcher()
+	for _, tDef := range doesMatchTests {
+		for _, tCase := range tDef.tests {
+			result, err := m.DoesMatch(tDef.query, tCase.data)
+
+			if !sameError(tCase.err, err) {
+				t.Errorf("[%s|%s] Mismatch on error => expected: %#+v, have: %#+v", tDef.symbol, tCase.symbol, tCase.err, err)
+			}
+			if result != tCase.expected {
+				t.Errorf("[%s|%s] Mismatch => expected: %#+v, have: %#+v", tDef.symbol, tCase.symbol, tCase.expected, result)
+			}
+		}
+	}
+}
+
+func TestMatcherOptions(t *testing.T) {
+	type subTestCase struct {
+		symbol   string
+		query    map[string]interface{}
+		expected bool
+		err      error
+	}
+
+	type testCase struct {
+		symbol string
+		opts   []Option
+		data   map[string]interface{}
+		tests  []subTestCase
+	}
+
+	data := map[string]interface{}{
+		"a": map[string]interface{}{
+			"b":      100,
+			"c/d":    "slash",
+			"e~f":    "tilde",
+			"g.h":    "dot",
+			"Mixed":  "case",
+			"*":      "star",
+			"01":     "zero one",
+			"UPPER":  "upper",
+			"Upper":  "title",
+			"list":   []interface{}{"x", "y"},
+			"nested": map[string]interface{}{"Key": 1},
+		},
+	}
+
+	var tests = []testCase{
+		{
+			symbol: "AA",
+			opts:   []Option{WithSeparator("/")},
+			data:   data,
+			tests: []subTestCase{
+				{"aa", map[string]interface{}{"a/b": 100}, true, nil},
+				{"ab", map[string]interface{}{"a/g.h": "dot"}, true, nil},
+				{"ac", map[string]interface{}{`a/c\/d`: "slash"}, true, nil},
+				{"ad", map[string]interface{}{"a/list/-1": "y"}, true, nil},
+				{"ae", map[string]interface{}{"a/list/*": "x"}, true, nil},
+				{"ba", map[string]interface{}{"a.b": 100}, false, nil},
+			},
+		},
+		{
+			symbol: "AB",
+			opts:   []Option{WithSeparator("::")},
+			data:   data,
+			tests: []subTestCase{
+				{"aa", map[string]interface{}{"a::b": 100}, true, nil},
+				{"ab", map[string]interface{}{"a::nested::Key": 1}, true, nil},
+				{"ba", map[string]interface{}{"a:b": 100}, false, nil},
+			},
+		},
+		{
+			symbol: "BA",
+			opts:   []Option{WithPathSyntax(PATH_SYNTAX_JSON_POINTER)},
+			data:   data,
+			tests: []subTestCase{
+				{"aa", map[string]interface{}{"/a/b": 100}, true, nil},
+				{"ab", map[string]interface{}{"/a/c~1d": "slash"}, true, nil},
+				{"ac", map[string]interface{}{"/a/e~0f": "tilde"}, true, nil},
+				{"ad", map[string]interface{}{"/a/g.h": "dot"}, true, nil},
+				{"ae", map[string]interface{}{"/a/*": "star"}, true, nil},
+				{"af", map[string]interface{}{"/a/01": "zero one"}, true, nil},
+				{"ag", map[string]interface{}{"/a/list/1": "y"}, true, nil},
+				{"ah", map[string]interface{}{"/a/b": map[string]interface{}{"$gt": 10}, "!$or": map[string]interface{}{"/a/list/0": "y"}}, true, nil},
+				// no wildcards, negative indexes nor leading zeros
+				{"ba", map[string]interface{}{"/a/list/*": "x"}, false, nil},
+				{"bb", map[string]interface{}{"/a/list/-1": "y"}, false, nil},
+				{"bc", map[string]interface{}{"/a/list/01": "y"}, false, nil},
+				{"bd", map[string]interface{}{"a.b": 100}, false, ErrInvalidPath},
+				{"be", map[string]interface{}{"/a/e~2f": "tilde"}, false, ErrInvalidPath},
+			},
+		},
+		{
+			symbol: "CA",
+			opts:   []Option{WithCaseInsensitiveKeys(true)},
+			data:   data,
+			tests: []subTestCase{
+				{"aa", map[string]interface{}{"A.B": 100}, true, nil},
+				{"ab", map[string]interface{}{"a.mixed": "case"}, true, nil},
+				{"ac", map[string]interface{}{"a.NESTED.key": 1}, true, nil},
+				// exact match is preferred, otherwise the first key in sort order
+				{"ad", map[string]interface{}{"a.Upper": "title"}, true, nil},
+				{"ae", map[string]interface{}{"a.upper": "upper"}, true, nil},
+				{"ba", map[string]interface{}{"a.mixedd": "case"}, false, nil},
+			},
+		},
+		{
+			symbol: "CB",
+			data:   data,
+			tests: []subTestCase{
+				{"aa", map[string]interface{}{"A.B": 100}, false, nil},
+				{"ab", map[string]interface{}{"a.mixed": "case"}, false, nil},
+			},
+		},
+	}
+
+	for _, tDef := range tests {
+		m := NewMatcher(tDef.opts...)
+		for _, tCase := range tDef.tests {
+			result, err := m.DoesMatch(tCase.query, tDef.data)
+			if !sameError(tCase.err, err) {
+				t.Errorf("[%s|%s] Mismatch on error => expected: %#+v, have: %#+v", tDef.symbol, tCase.symbol, tCase.err, err)
+			}
+			if result != tCase.expected {
+				t.Errorf("[%s|%s] Mismatch => expected: %#+v, have: %#+v", tDef.symbol, tCase.symbol, tCase.expected, result)
+			}
+
+			// compiled query has to behave the same way
+			q, err := m.Compile(tCase.query)
+			if err == nil {
+				result, err = q.Match(tDef.data)
+			}
+			if !sameError(tCase.err, err) {
+				t.Errorf("[%s|%s] Mismatch on compiled error => expected: %#+v, have: %#+v", tDef.symbol, tCase.symbol, tCase.err, err)
+			}
+			if result != tCase.expected {
+				t.Errorf("[%s|%s] Mismatch on compiled => expected: %#+v, have: %#+v", tDef.symbol, tCase.symbol, tCase.expected, result)
+			}
+		}
+	}
+}
+
+func TestMatcherInvalidPathIsReportedByValidate(t *testing.T) {
+	m := NewMatcher(WithPathSyntax(PATH_SYNTAX_JSON_POINTER))
+	err := m.Validate(map[string]interface{}{"$or": []interface{}{map[string]interface{}{"a": 1}}})
+
+	var vErr *ValidationError
+	if !errors.As(err, &vErr) || vErr.Path != "/$or/0/a" || !errors.Is(err, ErrInvalidPath) {
+		t.Errorf("Expected invalid path at /$or/0/a, have: %v", err)
+	}
+}
+
+func TestMatcherJoinPath(t *testing.T) {
+	type testCase struct {
+		symbol   string
+		opts     []Option
+		keys     []string
+		expected string
+	}
+
+	var tests = []testCase{
+		{"aa", nil, []string{"a", "b.c"}, `a.b\.c`},
+		{"ab", []Option{WithSeparator("/")}, []string{"a", "b.c", "d/e"}, `a/b.c/d\/e`},
+		{"ac", []Option{WithPathSyntax(PATH_SYNTAX_JSON_POINTER)}, []string{"a", "b/c", "d~e", "*"}, `/a/b~1c/d~0e/*`},
+		{"ad", []Option{WithPathSyntax(PATH_SYNTAX_JSON_POINTER)}, nil, ``},
+	}
+
+	for _, tc := range tests {
+		m := NewMatcher(tc.opts...)
+		have := m.JoinPath(tc.keys...)
+		if have != tc.expected {
+			t.Errorf("[%s] Mismatch => expected: %q, have: %q", tc.symbol, tc.expected, have)
+		}
+
+		// path built by matcher has to point to the value
+		if len(tc.keys) == 0 {
+			continue
+		}
+		var data interface{} = "found"
+		for i := len(tc.keys) - 1; i >= 0; i-- {
+			data = map[string]interface{}{tc.keys[i]: data}
+		}
+		matched, err := m.DoesMatch(map[string]interface{}{have: "found"}, data.(map[string]interface{}))
+		if err != nil || !matched {
+			t.Errorf("[%s] Path %q does not point to the value (err: %v)", tc.symbol, have, err)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package gjsonquery
+
+import (
+	"cmp"
+	"encoding/json"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+)
+
+type numberKind int
+
+const (
+	numberInt numberKind = iota + 1
+	numberUint
+	numberFloat
+	numberBig
+)
+
+// number holds value of any Go numeric type (json.Number and *big.Int included) without losing precision.
+type number struct {
+	kind numberKind
+	i    int64
+	u    uint64
+	f    float64
+	b    *big.Int
+}
+
+// bigKey is a map key of integers out of int64 and uint64 range.
+type bigKey string
+
+// toNumber converts numeric value into number, ok is false for non numeric values.
+func toNumber(v interface{}) (n number, ok bool) {
+	switch c := v.(type) {
+	case int:
+		return number{kind: numberInt, i: int64(c)}, true
+	case int8:
+		return number{kind: numberInt, i: int64(c)}, true
+	case int16:
+		return number{kind: numberInt, i: int64(c)}, true
+	case int32:
+		return number{kind: numberInt, i: int64(c)}, true
+	case int64:
+		return number{kind: numberInt, i: c}, true
+	case uint:
+		return number{kind: numberUint, u: uint64(c)}, true
+	case uint8:
+		return number{kind: numberUint, u: uint64(c)}, true
+	case uint16:
+		return number{kind: numberUint, u: uint64(c)}, true
+	case uint32:
+		return number{kind: numberUint, u: uint64(c)}, true
+	case uint64:
+		return number{kind: numberUint, u: c}, true
+	case float32:
+		return number{kind: numberFloat, f: float64(c)}, true
+	case float64:
+		return number{kind: numberFloat, f: c}, true
+	case *big.Int:
+		if c != nil {
+			return number{kind: numberBig, b: c}, true
+		}
+	case json.Number:
+		if i, err := strconv.ParseInt(string(c), 10, 64); err == nil {
+			return number{kind: numberInt, i: i}, true
+		}
+		if u, err := strconv.ParseUint(string(c), 10, 64); err == nil {
+			return number{kind: numberUint, u: u}, true
+		}
+		if b, ok := new(big.Int).SetString(string(c), 10); ok {
+			return number{kind: numberBig, b: b}, true
+		}
+		// numbers out of float64 range are rounded to infinity (or zero)
+		if f, err := strconv.ParseFloat(string(c), 64); err == nil || isRangeError(err) {
+			return number{kind: numberFloat, f: f}, true
+		}
+	}
+	return number{}, false
+}
+
+func isRangeError(err error) bool {
+	numErr, ok := err.(*strconv.NumError)
+	return ok && numErr.Err == strconv.ErrRange
+}
+
+func (n number) isNaN() bool {
+	return n.kind == numberFloat && math.IsNaN(n.f)
+}
+
+// normalized returns canonical form of the number.
+// Integral values are kept as int64 (uint64 when too big for int64, *big.Int when too big for both),
+// everything else as float64.
+func (n number) normalized() number {
+	switch n.kind {
+	case numberUint:
+		if n.u <= math.MaxInt64 {
+			return number{kind: numberInt, i: int64(n.u)}
+		}
+	case numberFloat:
+		if n.f != math.Trunc(n.f) || math.IsInf(n.f, 0) {
+			// fraction, NaN or infinity
+			return number{kind: numberFloat, f: n.f}
+		}
+		if n.f >= math.MinInt64 && n.f < math.MaxInt64 {
+			return number{kind: numberInt, i: int64(n.f)}
+		}
+		if n.f >= 0 && n.f < math.MaxUint64 {
+			return number{kind: numberUint, u: uint64(n.f)}
+		}
+		b, _ := new(big.Float).SetFloat64(n.f).Int(nil)
+		return number{kind: numberBig, b: b}
+	case numberBig:
+		if n.b.IsInt64() {
+			return number{kind: numberInt, i: n.b.Int64()}
+		}
+		if n.b.IsUint64() {
+			return number{kind: numberUint, u: n.b.Uint64()}
+		}
+	}
+	return n
+}
+
+// value returns the number as Go value: int when it fits, then uint64, *big.Int and float64.
+func (n number) value() interface{} {
+	n = n.normalized()
+	switch n.kind {
+	case numberInt:
+		if n.i >= math.MinInt && n.i <= math.MaxInt {
+			return int(n.i)
+		}
+		return n.i
+	case numberUint:
+		return n.u
+	case numberBig:
+		return n.b
+	}
+	return n.f
+}
+
+// bigFloat returns exact value of the number, it must not be NaN.
+func (n number) bigFloat() *big.Float {
+	switch n.kind {
+	case numberInt:
+		return new(big.Float).SetInt64(n.i)
+	case numberUint:
+		return new(big.Float).SetUint64(n.u)
+	case numberBig:
+		return new(big.Float).SetInt(n.b)
+	}
+	return new(big.Float).SetFloat64(n.f)
+}
+
+// compareNumbers orders numbers exactly (-1, 0, +1), NaN has to be handled by the caller.
+func compareNumbers(a, b number) int {
+	a, b = a.normalized(), b.normalized()
+	switch {
+	case a.kind == numberInt && b.kind == numberInt:
+		return cmp.Compare(a.i, b.i)
+	case a.kind == numberUint && b.kind == numberUint:
+		return cmp.Compare(a.u, b.u)
+	case a.kind == numberFloat && b.kind == numberFloat:
+		return cmp.Compare(a.f, b.f)
+	// normalized int64 is always smaller than normalized uint64
+	case a.kind == numberInt && b.kind == numberUint:
+		return -1
+	case a.kind == numberUint && b.kind == numberInt:
+		return 1
+	}
+	return a.bigFloat().Cmp(b.bigFloat())
+}
+
+// key returns value usable as a map key, equal numbers have equal keys.
+func (n number) key() interface{} {
+	n = n.normalized()
+	switch n.kind {
+	case numberInt:
+		return n.i
+	case numberUint:
+		return n.u
+	case numberBig:
+		return bigKey(n.b.String())
+	}
+	return n.f
+}
+
+func numbersEqual(a, b number) bool {
+	// NaN is never equal (as for float64)
+	if a.isNaN() || b.isNaN() {
+		return false
+	}
+	return compareNumbers(a, b) == 0
+}
+
+// equal compares values, numbers of different Go types are compared by value unless strict.
+// Lists and objects are compared element by element, other values which can not be compared with == (e.g. typed slices) deeply.
+// Go values (e.g. time.Time in query) are compared in the form of values fetched from data, see plainValue.
+func equal(a, b interface{}, strict bool) bool {
+	a, b = comparableValue(a), comparableValue(b)
+
+	if !strict {
+		if aN, ok := toNumber(a); ok {
+			if bN, ok := toNumber(b); ok {
+				return numbersEqual(aN, bN)
+			}
+		}
+	}
+
+	// == panics only for two values of the same type which is not comparable
+	if isScalar(a) || isScalar(b) {
+		return a == b
+	}
+
+	switch aCasted := a.(type) {
+	case []interface{}:
+		bCasted, ok := b.([]interface{})
+		if !ok || len(aCasted) != len(bCasted) {
+			return false
+		}
+		for i := range aCasted {
+			if !equal(aCasted[i], bCasted[i], strict) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		bCasted, ok := b.(map[string]interface{})
+		if !ok || len(aCasted) != len(bCasted) {
+			return false
+		}
+		for key, aValue := range aCasted {
+			bValue, ok := bCasted[key]
+			if !ok || !equal(aValue, bValue, strict) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if !reflect.ValueOf(a).Comparable() || !reflect.ValueOf(b).Comparable() {
+		return reflect.DeepEqual(a, b)
+	}
+	return a == b
+}
+
+// comparableValue converts Go value with plainValue, values referencing themselves are kept as they are.
+func comparableValue(v interface{}) interface{} {
+	if isPlain(v) {
+		return v
+	}
+	if plain, err := plainValue(v); err == nil {
+		return plain
+	}
+	return v
+}
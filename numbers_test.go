@@ -0,0 +1,69 @@
+package gjsonquery_test
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"testing"
+
+	. "github.com/szpakas/gjsonquery"
+)
+
+func TestEqual(t *testing.T) {
+	type testCase struct {
+		symbol   string
+		a, b     interface{}
+		expected bool
+		strict   bool
+	}
+
+	var tests = []testCase{
+		// loose
+		{"aa", 100, 100.0, true, false},
+		{"ab", int8(-5), -5.0, true, false},
+		{"ac", uint64(math.MaxUint64), float64(math.MaxUint64), false, false}, // float64 rounds up to 2^64
+		{"ad", uint64(1 << 63), float64(1 << 63), true, false},
+		{"ae", int64(math.MaxInt64), float64(math.MaxInt64), false, false}, // float64 rounds up to 2^63
+		{"af", int64(math.MinInt64), float64(math.MinInt64), true, false},
+		{"ag", uint64(100), int64(100), true, false},
+		{"ah", json.Number("18446744073709551615"), uint64(math.MaxUint64), true, false},
+		{"ai", json.Number("0.5"), 0.5, true, false},
+		{"aj", math.Copysign(0, -1), 0, true, false},
+		{"ak", math.NaN(), math.NaN(), false, false},
+		{"al", math.Inf(1), math.Inf(1), true, false},
+		{"am", 100, "100", false, false},
+		{"an", "a", "a", true, false},
+		{"ao", nil, nil, true, false},
+		{"ap", json.Number("abc"), json.Number("abc"), true, false},
+		{"aq", json.Number("18446744073709551616"), big.NewInt(0).Lsh(big.NewInt(1), 64), true, false},
+		{"ar", float64(1 << 64), json.Number("18446744073709551616"), true, false},
+		{"as", big.NewInt(-7), int8(-7), true, false},
+		{"at", json.Number("9007199254740993"), 9007199254740992.0, false, false},
+		// strict
+		{"ba", 100, 100.0, false, true},
+		{"bb", 100, 100, true, true},
+		{"bc", json.Number("100"), 100, false, true},
+		// lists and objects
+		{"ca", []interface{}{1, "a"}, []interface{}{1.0, "a"}, true, false},
+		{"cb", []interface{}{1, "a"}, []interface{}{"a", 1}, false, false},
+		{"cc", map[string]interface{}{"x": 1}, map[string]interface{}{"x": 1.0}, true, false},
+		{"cd", map[string]interface{}{"x": 1}, map[string]interface{}{"y": 1}, false, false},
+		{"ce", map[string]interface{}{"x": []interface{}{1}}, map[string]interface{}{"x": []interface{}{1}}, true, false},
+		{"cf", []interface{}{1}, map[string]interface{}{}, false, false},
+		{"cg", []interface{}{1}, 1, false, false},
+		{"ch", []interface{}{1}, []interface{}{1.0}, false, true},
+		// other values which can not be compared with ==
+		{"da", []string{"a"}, []string{"a"}, true, false},
+		{"db", []string{"a"}, []string{"b"}, false, false},
+		{"dc", map[string]int{"a": 1}, map[string]int{"a": 1}, true, false},
+	}
+
+	for _, tc := range tests {
+		if have := Equal(tc.a, tc.b, tc.strict); have != tc.expected {
+			t.Errorf("[%s] Mismatch for %#v == %#v => expected: %t, have: %t", tc.symbol, tc.a, tc.b, tc.expected, have)
+		}
+		if have := Equal(tc.b, tc.a, tc.strict); have != tc.expected {
+			t.Errorf("[%s] Mismatch for %#v == %#v => expected: %t, have: %t", tc.symbol, tc.b, tc.a, tc.expected, have)
+		}
+	}
+}
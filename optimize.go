@@ -0,0 +1,269 @@
+package gjsonquery
+
+import (
+	"sync/atomic"
+)
+
+// WithOptimizer makes Compile reorder children of AND/OR nodes (and comparators of a column) by estimated cost,
+// so cheap checks on top level keys run before deep paths, wildcard scans and regular expressions.
+// Results are preserved: parts of the query which can fail on data type are never moved ahead of parts evaluated before them,
+// so data which does not match is never reported with an error. Data which DoesMatch reports with an error
+// may be found not to match instead (see Query.Optimize).
+func WithOptimizer(enabled bool) Option {
+	return func(o *options) {
+		o.optimize = enabled
+	}
+}
+
+// WithStatistics makes compiled query count how often children of AND/OR nodes are evaluated and matched.
+// Statistics are used by Query.Optimize to estimate selectivity, they are not collected by default.
+func WithStatistics(enabled bool) Option {
+	return func(o *options) {
+		o.statistics = enabled
+	}
+}
+
+// Optimize returns a copy of the query with children of AND/OR nodes reordered by estimated cost and selectivity.
+//
+// AND evaluates first children which are cheap and likely to fail, OR children which are cheap and likely to match.
+// Only children which can not fail on data type ("$is", "$in", "$not" and "$exists") are moved ahead of children
+// preceding them, so an error is never reported where DoesMatch finds a mismatch (Go values referencing themselves excepted).
+// Selectivity is observed by the query compiled with WithStatistics, otherwise every child is assumed to match half of the time.
+// The copy shares statistics with q, so Optimize can be called periodically while the query is in use.
+// The original query is not modified and remains safe for concurrent use.
+func (q *Query) Optimize() *Query {
+	optimized := *q
+	optimized.root = optimizeNode(q.root)
+	return &optimized
+}
+
+// nodeStats counts evaluations of a child node which finished without error.
+type nodeStats struct {
+	evaluated atomic.Uint64
+	matched   atomic.Uint64
+}
+
+func (s *nodeStats) record(matched bool) {
+	s.evaluated.Add(1)
+	if matched {
+		s.matched.Add(1)
+	}
+}
+
+// matchRate estimates probability of a match, with Laplace smoothing so a child without observations scores 0.5.
+func (s *nodeStats) matchRate() float64 {
+	if s == nil {
+		return 0.5
+	}
+	return float64(s.matched.Load()+1) / float64(s.evaluated.Load()+2)
+}
+
+// collectStatistics enables statistics of all AND/OR nodes in the tree.
+func collectStatistics(n node) {
+	switch v := n.(type) {
+	case *andNode:
+		v.stats = newNodeStats(v.children)
+	case *orNode:
+		v.stats = newNodeStats(v.children)
+	case *notNode:
+		collectStatistics(v.child)
+	}
+}
+
+func newNodeStats(children []node) []*nodeStats {
+	stats := make([]*nodeStats, len(children))
+	for i, child := range children {
+		stats[i] = &nodeStats{}
+		collectStatistics(child)
+	}
+	return stats
+}
+
+// -- cost estimation, units are roughly cost of a map lookup
+
+const (
+	costPathElement = 1
+	// costWildcard multiplies cost of checks on wildcard paths
+	costWildcard = 8
+)
+
+var comparatorCosts = map[comparatorType]float64{
+	COMPARATOR_IS:          1,
+	COMPARATOR_IN:          1,
+	COMPARATOR_EXISTS:      1,
+	COMPARATOR_GT:          2,
+	COMPARATOR_GTE:         2,
+	COMPARATOR_LT:          2,
+	COMPARATOR_LTE:         2,
+	COMPARATOR_STARTS_WITH: 2,
+	COMPARATOR_ENDS_WITH:   2,
+	COMPARATOR_IIS:         3,
+	COMPARATOR_CONTAINS:    4,
+	COMPARATOR_REGEX:       16,
+}
+
+func estimateCost(n node) float64 {
+	switch v := n.(type) {
+	case *andNode:
+		return estimateCostAll(v.children)
+	case *orNode:
+		return estimateCostAll(v.children)
+	case *notNode:
+		return estimateCost(v.child)
+	case *columnNode:
+		cost := 0.0
+		for i := range v.checks {
+			cost += v.checks[i].cost()
+		}
+		if v.multi {
+			cost *= costWildcard
+		}
+		return float64(len(v.path))*costPathElement + cost
+	case *comparatorNode:
+		return v.check.cost()
+	}
+	return 1
+}
+
+func estimateCostAll(children []node) float64 {
+	cost := 0.0
+	for _, child := range children {
+		cost += estimateCost(child)
+	}
+	return cost
+}
+
+func (c *check) cost() float64 {
+	if cost, ok := comparatorCosts[c.cmp.cType]; ok {
+		return cost
+	}
+	return 1
+}
+
+// canFail reports whether evaluation of the node can return an error for some data.
+// Errors of Go values referencing themselves (ErrCyclicValue) are not considered.
+func canFail(n node) bool {
+	switch v := n.(type) {
+	case *andNode:
+		return canFailAny(v.children)
+	case *orNode:
+		return canFailAny(v.children)
+	case *notNode:
+		return canFail(v.child)
+	case *columnNode:
+		for i := range v.checks {
+			if v.checks[i].canFail() {
+				return true
+			}
+		}
+		return false
+	case *comparatorNode:
+		return v.check.canFail()
+	}
+	return true
+}
+
+func canFailAny(children []node) bool {
+	for _, child := range children {
+		if canFail(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// canFail reports whether the check can return an error, expectations are validated by Compile.
+func (c *check) canFail() bool {
+	switch c.cmp.cType {
+	case COMPARATOR_IS, COMPARATOR_IN, COMPARATOR_EXISTS:
+		return false
+	}
+	return true
+}
+
+// -- reordering
+
+// optimizeNode returns a copy of the tree with reordered children, leaves are shared.
+func optimizeNode(n node) node {
+	switch v := n.(type) {
+	case *andNode:
+		// AND is decided by the first mismatch
+		children, stats := optimizeChildren(v.children, v.stats, func(rate float64) float64 { return 1 - rate })
+		return &andNode{children: children, stats: stats}
+	case *orNode:
+		// OR is decided by the first match
+		children, stats := optimizeChildren(v.children, v.stats, func(rate float64) float64 { return rate })
+		return &orNode{children: children, stats: stats}
+	case *notNode:
+		return &notNode{child: optimizeNode(v.child)}
+	case *columnNode:
+		optimized := *v
+		ranks := make([]float64, len(v.checks))
+		fallible := make([]bool, len(v.checks))
+		for i := range v.checks {
+			ranks[i], fallible[i] = v.checks[i].cost(), v.checks[i].canFail()
+		}
+		optimized.checks = make([]check, len(v.checks))
+		for i, j := range orderByRank(ranks, fallible) {
+			optimized.checks[i] = v.checks[j]
+		}
+		return &optimized
+	}
+	return n
+}
+
+// optimizeChildren orders children by expected cost of reaching the decision: cost / probability of deciding.
+// Ties keep the original (sorted key) order.
+func optimizeChildren(children []node, stats []*nodeStats, decides func(rate float64) float64) ([]node, []*nodeStats) {
+	optimizedChildren := make([]node, len(children))
+	ranks := make([]float64, len(children))
+	fallible := make([]bool, len(children))
+	for i, child := range children {
+		optimizedChildren[i] = optimizeNode(child)
+		var s *nodeStats
+		if stats != nil {
+			s = stats[i]
+		}
+		ranks[i] = estimateCost(optimizedChildren[i]) / decides(s.matchRate())
+		fallible[i] = canFail(optimizedChildren[i])
+	}
+
+	optimized := make([]node, len(children))
+	var optimizedStats []*nodeStats
+	if stats != nil {
+		optimizedStats = make([]*nodeStats, len(children))
+	}
+	for i, j := range orderByRank(ranks, fallible) {
+		optimized[i] = optimizedChildren[j]
+		if stats != nil {
+			optimizedStats[i] = stats[j]
+		}
+	}
+	return optimized, optimizedStats
+}
+
+// orderByRank returns indexes of items ordered by rank, ties keep the original order.
+// Item which can fail is placed only after all items preceding it, so it is evaluated only when they did not decide.
+func orderByRank(ranks []float64, fallible []bool) []int {
+	order := make([]int, 0, len(ranks))
+	placed := make([]bool, len(ranks))
+	// first is the first item not placed yet, all items before it are placed
+	first := 0
+	for len(order) < len(ranks) {
+		next := -1
+		for i := first; i < len(ranks); i++ {
+			if placed[i] || (fallible[i] && i != first) {
+				continue
+			}
+			if next == -1 || ranks[i] < ranks[next] {
+				next = i
+			}
+		}
+		order = append(order, next)
+		placed[next] = true
+		for first < len(ranks) && placed[first] {
+			first++
+		}
+	}
+	return order
+}
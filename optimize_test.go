@@ -0,0 +1,211 @@
+package gjsonquery_test
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+
+	. "github.com/szpakas/gjsonquery"
+)
+
+func TestQueryOptimizeResults(t *testing.T) {
+	for _, tDef := range doesMatchTests {
+		q, err := Compile(tDef.query, WithOptimizer(true), WithStatistics(true))
+		if err != nil {
+			continue
+		}
+
+		// second pass runs on the query reordered with statistics of the first one
+		for pass := 0; pass < 2; pass++ {
+			for _, tCase := range tDef.tests {
+				result, err := q.Match(tCase.data)
+				// reordering may replace an error with a mismatch, results of error free evaluation have to be preserved
+				if tCase.err != nil {
+					continue
+				}
+				if result != tCase.expected || err != nil {
+					t.Errorf("[%s|%s|%d] Mismatch => expected: %#+v, have: %#+v (err: %v)", tDef.symbol, tCase.symbol, pass, tCase.expected, result, err)
+				}
+			}
+			q = q.Optimize()
+		}
+	}
+}
+
+func TestQueryOptimizeNoNewErrors(t *testing.T) {
+	var tests = []struct {
+		symbol string
+		query  map[string]interface{}
+		data   map[string]interface{}
+	}{
+		// "$contains" does not fail on missing value, "$gt" does
+		{"aa", map[string]interface{}{"a": map[string]interface{}{"$contains": "x", "$gt": 1}}, map[string]interface{}{}},
+		{"ab", map[string]interface{}{"a": map[string]interface{}{"$iis": "x", "$lt": 1}}, map[string]interface{}{"a": "y"}},
+		{"ac", map[string]interface{}{"a.b.c.d": 1, "b": map[string]interface{}{"$gt": 1}}, map[string]interface{}{"b": "x"}},
+		{"ad", map[string]interface{}{"$or": map[string]interface{}{"a.b.c.d": nil, "b": map[string]interface{}{"$gt": 1}}}, map[string]interface{}{"b": "x"}},
+	}
+
+	for _, tc := range tests {
+		expected, err := DoesMatch(tc.query, tc.data)
+		if err != nil {
+			t.Fatalf("[%s] unexpected error: %v", tc.symbol, err)
+		}
+		q, err := Compile(tc.query, WithOptimizer(true), WithStatistics(true))
+		if err != nil {
+			t.Fatalf("[%s] unexpected compile error: %v", tc.symbol, err)
+		}
+		for pass := 0; pass < 2; pass++ {
+			if result, err := q.Match(tc.data); result != expected || err != nil {
+				t.Errorf("[%s|%d] Mismatch => expected: %t, have: %t (err: %v)", tc.symbol, pass, expected, result, err)
+			}
+			q = q.Optimize()
+		}
+	}
+}
+
+// childColumns lists columns of the root children in evaluation order.
+func childColumns(t *testing.T, q *Query, data map[string]interface{}) []string {
+	e, err := q.Explain(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(e.Children) == 1 && e.Children[0].Type == EXPLAIN_OR {
+		e = e.Children[0]
+	}
+	columns := make([]string, len(e.Children))
+	for i, child := range e.Children {
+		columns[i] = child.Column
+	}
+	return columns
+}
+
+func TestQueryOptimizeCost(t *testing.T) {
+	var tests = []struct {
+		symbol   string
+		query    map[string]interface{}
+		expected []string
+	}{
+		{"aa", map[string]interface{}{"a": map[string]interface{}{"$regex": "x"}, "b": 1}, []string{"b", "a"}},
+		{"ab", map[string]interface{}{"a.*.c": 1, "b": map[string]interface{}{"$in": []interface{}{"x"}}}, []string{"b", "a.*.c"}},
+		{"ac", map[string]interface{}{"a.b.c.d": 1, "z": 1}, []string{"z", "a.b.c.d"}},
+		// ties keep sorted order
+		{"ad", map[string]interface{}{"b": 1, "a": 2}, []string{"a", "b"}},
+		// checks which can fail on data type are not moved ahead
+		{"ae", map[string]interface{}{"a.*.c": 1, "b": map[string]interface{}{"$contains": "x"}}, []string{"a.*.c", "b"}},
+		{"af", map[string]interface{}{"a.b.c.d": 1, "b": map[string]interface{}{"$gt": 1}, "c": 1}, []string{"c", "a.b.c.d", "b"}},
+		{"ba", map[string]interface{}{"$or": map[string]interface{}{"a": map[string]interface{}{"$regex": "x"}, "b": 1}}, []string{"b", "a"}},
+	}
+
+	for _, tc := range tests {
+		plain, err := Compile(tc.query)
+		if err != nil {
+			t.Fatalf("[%s] unexpected compile error: %v", tc.symbol, err)
+		}
+		q, _ := Compile(tc.query, WithOptimizer(true))
+
+		columns := childColumns(t, q, map[string]interface{}{})
+		if len(columns) != len(tc.expected) {
+			t.Fatalf("[%s] Mismatch on order => expected: %v, have: %v", tc.symbol, tc.expected, columns)
+		}
+		for i := range columns {
+			if columns[i] != tc.expected[i] {
+				t.Errorf("[%s] Mismatch on order => expected: %v, have: %v", tc.symbol, tc.expected, columns)
+				break
+			}
+		}
+
+		// optimization does not change the query itself
+		plainJSON, _ := json.Marshal(plain)
+		optimizedJSON, _ := json.Marshal(q)
+		if string(plainJSON) != string(optimizedJSON) {
+			t.Errorf("[%s] Mismatch on JSON => expected: %s, have: %s", tc.symbol, plainJSON, optimizedJSON)
+		}
+	}
+}
+
+func TestQueryOptimizeStatistics(t *testing.T) {
+	var tests = []struct {
+		symbol   string
+		query    map[string]interface{}
+		data     map[string]interface{}
+		expected []string
+	}{
+		// AND: "b" never matches so it decides first
+		{"aa", map[string]interface{}{"a": 1, "b": 1}, map[string]interface{}{"a": 1, "b": 2}, []string{"b", "a"}},
+		{"ab", map[string]interface{}{"a": 1, "b": 1}, map[string]interface{}{"a": 2, "b": 1}, []string{"a", "b"}},
+		// OR: "b" always matches so it decides first
+		{"ba", map[string]interface{}{"$or": map[string]interface{}{"a": 1, "b": 1}}, map[string]interface{}{"a": 2, "b": 1}, []string{"b", "a"}},
+		{"bb", map[string]interface{}{"$or": map[string]interface{}{"a": 1, "b": 1}}, map[string]interface{}{"a": 1, "b": 2}, []string{"a", "b"}},
+	}
+
+	for _, tc := range tests {
+		q, err := Compile(tc.query, WithStatistics(true))
+		if err != nil {
+			t.Fatalf("[%s] unexpected compile error: %v", tc.symbol, err)
+		}
+		// "a" is evaluated first, "b" only when "a" does not decide
+		for i := 0; i < 100; i++ {
+			if _, err := q.Match(tc.data); err != nil {
+				t.Fatalf("[%s] unexpected error: %v", tc.symbol, err)
+			}
+		}
+		optimized := q.Optimize()
+
+		columns := childColumns(t, optimized, tc.data)
+		if len(columns) != 2 || columns[0] != tc.expected[0] || columns[1] != tc.expected[1] {
+			t.Errorf("[%s] Mismatch on order => expected: %v, have: %v", tc.symbol, tc.expected, columns)
+		}
+		// original query is not modified
+		if columns := childColumns(t, q, tc.data); columns[0] != "a" {
+			t.Errorf("[%s] Original query reordered: %v", tc.symbol, columns)
+		}
+	}
+}
+
+func TestQueryOptimizeConcurrent(t *testing.T) {
+	q, err := Compile(map[string]interface{}{"a": 1, "$or": map[string]interface{}{"b": 1, "c": 1}}, WithStatistics(true))
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	data := map[string]interface{}{"a": 1, "c": 1}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				if matched, err := q.Match(data); !matched || err != nil {
+					t.Errorf("unexpected result: %v, %v", matched, err)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		if matched, err := q.Optimize().Match(data); !matched || err != nil {
+			t.Errorf("unexpected result of optimized query: %v, %v", matched, err)
+		}
+	}
+	wg.Wait()
+}
+
+func TestQueryOptimizeCanonicalJSON(t *testing.T) {
+	for _, query := range []string{
+		`{"a": {"!$is": 1, "$gt": 0, "$not": 2}}`,
+		`{"a": {"$not": 2, "!$is": 1, "$regex": "x", "!$regex": "y", "$exists": true}}`,
+	} {
+		plain, err := ParseQueryString(query)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		optimized, _ := ParseQueryString(query)
+		optimized = optimized.Optimize()
+
+		plainJSON, _ := json.Marshal(plain)
+		optimizedJSON, _ := json.Marshal(optimized)
+		if string(plainJSON) != string(optimizedJSON) {
+			t.Errorf("Mismatch on JSON of %s\nexpected => %s\n    have => %s", query, plainJSON, optimizedJSON)
+		}
+	}
+}
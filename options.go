@@ -0,0 +1,62 @@
+package gjsonquery
+
+// Option configures how queries are evaluated.
+type Option func(*options)
+
+type options struct {
+	strictNumbers       bool
+	separator           string
+	pathSyntax          PathSyntax
+	caseInsensitiveKeys bool
+	tracer              Tracer
+	optimize            bool
+	statistics          bool
+}
+
+// defaultOptions are used by the package level functions.
+var defaultOptions = newOptions(nil)
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		separator:  COLUMN_LEVEL_SEPARATOR,
+		pathSyntax: PATH_SYNTAX_DOT,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithStrictNumbers switches equality ($is, $in, $not, $contains on lists) to strict mode.
+// In strict mode numbers are equal only when they have the same Go type and value, e.g. int(100) does not match float64(100).
+// By default numbers are compared by value, which is what data decoded by encoding/json needs.
+func WithStrictNumbers(strict bool) Option {
+	return func(o *options) {
+		o.strictNumbers = strict
+	}
+}
+
+// WithSeparator sets separator of column path elements for PATH_SYNTAX_DOT (COLUMN_LEVEL_SEPARATOR by default).
+// Empty separator is ignored.
+func WithSeparator(separator string) Option {
+	return func(o *options) {
+		if separator != "" {
+			o.separator = separator
+		}
+	}
+}
+
+// WithPathSyntax selects how column names are read (PATH_SYNTAX_DOT by default).
+func WithPathSyntax(syntax PathSyntax) Option {
+	return func(o *options) {
+		o.pathSyntax = syntax
+	}
+}
+
+// WithCaseInsensitiveKeys makes lookup of map keys in data case insensitive.
+// Exact match is preferred when available.
+func WithCaseInsensitiveKeys(insensitive bool) Option {
+	return func(o *options) {
+		o.caseInsensitiveKeys = insensitive
+	}
+}
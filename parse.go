@@ -0,0 +1,66 @@
+package gjsonquery
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// ParseQuery decodes query from JSON text and compiles it, see Compile.
+//
+// Numbers are decoded exactly (json.Number) and normalised: integers become int
+// (uint64 or *big.Int when out of int range), other numbers float64.
+// Malformed JSON is reported with the same error as returned by json.Unmarshal.
+func ParseQuery(data []byte, opts ...Option) (*Query, error) {
+	return parseQuery(newOptions(opts), data)
+}
+
+// ParseQueryString decodes query from JSON string and compiles it, see ParseQuery.
+func ParseQueryString(query string, opts ...Option) (*Query, error) {
+	return ParseQuery([]byte(query), opts...)
+}
+
+// ParseQuery decodes query from JSON text and compiles it using Matcher configuration, see ParseQuery.
+func (m *Matcher) ParseQuery(data []byte) (*Query, error) {
+	return parseQuery(m.opts, data)
+}
+
+func parseQuery(o *options, data []byte) (*Query, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var query interface{}
+	err := dec.Decode(&query)
+	if err == nil {
+		// nothing but whitespace is allowed after the query
+		if _, tokenErr := dec.Token(); tokenErr != io.EOF {
+			err = json.Unmarshal(data, new(interface{}))
+		}
+	} else {
+		err = json.Unmarshal(data, new(interface{}))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return compile(o, normalizeNumbers(query))
+}
+
+// normalizeNumbers replaces json.Number values in decoded JSON with Go numbers.
+func normalizeNumbers(v interface{}) interface{} {
+	switch c := v.(type) {
+	case json.Number:
+		if n, ok := toNumber(c); ok {
+			return n.value()
+		}
+	case map[string]interface{}:
+		for key, value := range c {
+			c[key] = normalizeNumbers(value)
+		}
+	case []interface{}:
+		for i, value := range c {
+			c[i] = normalizeNumbers(value)
+		}
+	}
+	return v
+}
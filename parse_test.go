@@ -0,0 +1,94 @@
+package gjsonquery_test
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"testing"
+
+	. "github.com/szpakas/gjsonquery"
+)
+
+func TestParseQuery(t *testing.T) {
+	var tests = []struct {
+		symbol   string
+		query    string
+		data     map[string]interface{}
+		expected bool
+	}{
+		{"aa", `{"a": 100}`, map[string]interface{}{"a": 100}, true},
+		{"ab", `{"a": {"$gt": 9007199254740992}}`, map[string]interface{}{"a": int64(9007199254740993)}, true},
+		{"ac", `{"a": {"$lte": 18446744073709551615}}`, map[string]interface{}{"a": uint64(math.MaxUint64)}, true},
+		{"ad", `{"a": [18446744073709551616, 1.5]}`, map[string]interface{}{"a": new(big.Int).Lsh(big.NewInt(1), 64)}, true},
+		{"ae", `{"a": [18446744073709551616, 1.5]}`, map[string]interface{}{"a": json.Number("1.50")}, true},
+		{"af", `{"$or": [{"a": {"$lt": 0.5}}, {"b": "x"}]}`, map[string]interface{}{"a": 1, "b": "x"}, true},
+		{"ba", `{"a": 100}`, map[string]interface{}{"a": 101}, false},
+		{"bb", `{"a": {"$gt": 9007199254740992}}`, map[string]interface{}{"a": 9007199254740992.0}, false},
+	}
+
+	for _, tc := range tests {
+		q, err := ParseQueryString(tc.query)
+		if err != nil {
+			t.Fatalf("[%s] ParseQueryString failed: %s", tc.symbol, err)
+		}
+		matched, err := q.Match(tc.data)
+		if err != nil || matched != tc.expected {
+			t.Errorf("[%s] Mismatch => expected: %t, have: %t (err: %v)", tc.symbol, tc.expected, matched, err)
+		}
+	}
+}
+
+// numbers are normalised so strict mode matches Go ints
+func TestParseQueryStrictNumbers(t *testing.T) {
+	q, err := ParseQuery([]byte(`{"a": 100, "b": 1.5, "c": [1, 2]}`), WithStrictNumbers(true))
+	if err != nil {
+		t.Fatalf("ParseQuery failed: %s", err)
+	}
+	matched, err := q.Match(map[string]interface{}{"a": 100, "b": 1.5, "c": 2})
+	if err != nil || !matched {
+		t.Errorf("Mismatch => expected: true, have: %t (err: %v)", matched, err)
+	}
+
+	q, err = NewMatcher(WithStrictNumbers(true)).ParseQuery([]byte(`{"a": 100}`))
+	if err != nil {
+		t.Fatalf("Matcher.ParseQuery failed: %s", err)
+	}
+	matched, err = q.Match(map[string]interface{}{"a": 100.0})
+	if err != nil || matched {
+		t.Errorf("Mismatch => expected: false, have: %t (err: %v)", matched, err)
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	// malformed JSON is reported as by json.Unmarshal
+	for _, query := range []string{``, `{"a": 1`, `{"a": 1}}`, `{"a": 1} {}`, `{"a": tru}`} {
+		expectedErr := json.Unmarshal([]byte(query), new(interface{}))
+		if expectedErr == nil {
+			t.Fatalf("[%s] Unmarshal should fail", query)
+		}
+		q, err := ParseQueryString(query)
+		if q != nil || err == nil || err.Error() != expectedErr.Error() {
+			t.Errorf("[%s] Mismatch on error => expected: %v, have: %v", query, expectedErr, err)
+		}
+	}
+
+	// malformed query is reported as by Compile
+	var tests = []struct {
+		symbol string
+		query  string
+		err    error
+	}{
+		{"aa", `"a"`, ErrUnknownQueryType},
+		{"ab", `{"a": {"$bogus": 1}}`, ErrUnknownComparator},
+		{"ac", `{"a": {"$gt": true}}`, ErrUnknownExpectedType},
+	}
+	for _, tc := range tests {
+		q, err := ParseQueryString(tc.query)
+		if q != nil || !sameError(tc.err, err) {
+			t.Errorf("[%s] Mismatch on error => expected: %v, have: %v", tc.symbol, tc.err, err)
+		}
+		if _, ok := err.(*ValidationError); !ok {
+			t.Errorf("[%s] Expected *ValidationError, have: %#+v", tc.symbol, err)
+		}
+	}
+}
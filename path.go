@@ -0,0 +1,292 @@
+package gjsonquery
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PATH_WILDCARD used as a path element selects all elements of a list (or all values of a map).
+const PATH_WILDCARD string = "*"
+
+// pathElement is a single level of a column path.
+type pathElement struct {
+	key string
+	// index is used for lists, negative values count from the end of the list
+	index   int
+	isIndex bool
+	// wildcard selects every element on the level
+	wildcard bool
+}
+
+// PATH_ESCAPE makes the following character a part of the key, e.g. `k8s\.io/name` is a single key "k8s.io/name".
+// Escaped path elements are always map keys, `\*` is a key "*" and not a wildcard.
+const PATH_ESCAPE string = "\\"
+
+func newPathElement(key string) pathElement {
+	el := pathElement{key: key}
+	if key == PATH_WILDCARD {
+		el.wildcard = true
+	} else if i, err := strconv.Atoi(key); err == nil {
+		el.index, el.isIndex = i, true
+	}
+	return el
+}
+
+// PathSyntax selects how column names are split into path elements.
+type PathSyntax int
+
+const (
+	// PATH_SYNTAX_DOT splits column on the separator ("." by default), e.g. "items.0.sku" or "items.*.price".
+	PATH_SYNTAX_DOT PathSyntax = iota
+	// PATH_SYNTAX_JSON_POINTER reads column as RFC 6901 JSON Pointer, e.g. "/items/0/sku".
+	// Every reference token is a literal key (or list index), there are no wildcards nor negative indexes.
+	PATH_SYNTAX_JSON_POINTER
+)
+
+// splitColumn splits column name into path elements used by fetchPath.
+func splitColumn(o *options, column string) ([]pathElement, error) {
+	if o.pathSyntax == PATH_SYNTAX_JSON_POINTER {
+		return splitPointer(column)
+	}
+	return splitDotted(column, o.separator), nil
+}
+
+func splitDotted(column, separator string) []pathElement {
+	// fast path for columns without escaping
+	if !strings.Contains(column, PATH_ESCAPE) {
+		keys := strings.Split(column, separator)
+		path := make([]pathElement, len(keys))
+		for i, key := range keys {
+			path[i] = newPathElement(key)
+		}
+		return path
+	}
+
+	var (
+		path    []pathElement
+		key     []byte
+		escaped bool
+	)
+	for i := 0; i < len(column); i++ {
+		switch {
+		case strings.HasPrefix(column[i:], PATH_ESCAPE) && i+len(PATH_ESCAPE) < len(column):
+			i += len(PATH_ESCAPE)
+			key = append(key, column[i])
+			escaped = true
+		case strings.HasPrefix(column[i:], separator):
+			path = append(path, newPathElementEscaped(string(key), escaped))
+			key, escaped = key[:0], false
+			i += len(separator) - 1
+		default:
+			key = append(key, column[i])
+		}
+	}
+	return append(path, newPathElementEscaped(string(key), escaped))
+}
+
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+func splitPointer(column string) ([]pathElement, error) {
+	// empty pointer references the whole document
+	if column == "" {
+		return []pathElement{}, nil
+	}
+	if !strings.HasPrefix(column, "/") {
+		return nil, newQueryError("splitColumn", ErrInvalidPath, column)
+	}
+
+	tokens := strings.Split(column[1:], "/")
+	path := make([]pathElement, len(tokens))
+	for i, token := range tokens {
+		// "~" is allowed only as a part of "~0" or "~1"
+		if strings.Count(token, "~") != strings.Count(token, "~0")+strings.Count(token, "~1") {
+			return nil, newQueryError("splitColumn", ErrInvalidPath, column)
+		}
+		el := pathElement{key: pointerUnescaper.Replace(token)}
+		// array index: "0" or digits without leading zero
+		if index, err := strconv.Atoi(el.key); err == nil && index >= 0 && el.key == strconv.Itoa(index) {
+			el.index, el.isIndex = index, true
+		}
+		path[i] = el
+	}
+	return path, nil
+}
+
+func newPathElementEscaped(key string, escaped bool) pathElement {
+	if escaped {
+		return pathElement{key: key}
+	}
+	return newPathElement(key)
+}
+
+// EscapePathElement escapes key so it can be used as a single element of a column path (PATH_SYNTAX_DOT with default separator).
+func EscapePathElement(key string) string {
+	return escapeDotted(key, COLUMN_LEVEL_SEPARATOR)
+}
+
+func escapeDotted(key, separator string) string {
+	key = strings.Replace(key, PATH_ESCAPE, PATH_ESCAPE+PATH_ESCAPE, -1)
+	key = strings.Replace(key, separator, PATH_ESCAPE+separator, -1)
+	if key == PATH_WILDCARD {
+		key = PATH_ESCAPE + key
+	}
+	return key
+}
+
+// JoinPath builds column path (PATH_SYNTAX_DOT with default separator) from literal keys,
+// e.g. JoinPath("labels", "k8s.io/name") is `labels.k8s\.io/name`.
+// Keys are escaped so PATH_WILDCARD has to be appended separately to select all elements.
+func JoinPath(keys ...string) string {
+	return joinPath(defaultOptions, keys)
+}
+
+func joinPath(o *options, keys []string) string {
+	if o.pathSyntax == PATH_SYNTAX_JSON_POINTER {
+		pointer := ""
+		for _, key := range keys {
+			pointer = pointerAppend(pointer, key)
+		}
+		return pointer
+	}
+
+	escaped := make([]string, len(keys))
+	for i, key := range keys {
+		escaped[i] = escapeDotted(key, o.separator)
+	}
+	return strings.Join(escaped, o.separator)
+}
+
+// hasWildcard reports whether path can yield multiple values.
+func hasWildcard(path []pathElement) bool {
+	for _, el := range path {
+		if el.wildcard {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchPath obtains value from data.
+// For paths with wildcard result is a list of all values found (found is false if there are none).
+// Go values other than scalars are returned unconverted, see lazyValue.
+func fetchPath(o *options, data interface{}, path []pathElement) (result interface{}, found bool) {
+	if doc, ok := data.(Document); ok {
+		return fetchDocument(o, doc, path)
+	}
+
+	if hasWildcard(path) {
+		results := fetchAll(o, data, path, nil)
+		return results, len(results) > 0
+	}
+
+	var current interface{} = data
+	for _, el := range path {
+		next, exists := fetchElement(o, current, el)
+
+		// case: no key/index on current level -> failure
+		if !exists {
+			return nil, false
+		}
+		current = next
+	}
+	return lazyValue(current), true
+}
+
+// fetchAll appends to results every value reachable by the path.
+func fetchAll(o *options, data interface{}, path []pathElement, results []interface{}) []interface{} {
+	for i, el := range path {
+		if el.wildcard {
+			for _, item := range wildcardItems(data) {
+				results = fetchAll(o, item, path[i+1:], results)
+			}
+			return results
+		}
+
+		next, exists := fetchElement(o, data, el)
+		if !exists {
+			return results
+		}
+		data = next
+	}
+	return append(results, lazyValue(data))
+}
+
+// fetchElement descends one level into maps (by key) and lists (by index).
+// Other Go values (structs, typed maps and slices) are handled by fetchReflect.
+func fetchElement(o *options, data interface{}, el pathElement) (interface{}, bool) {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		next, exists := v[el.key]
+		if !exists && o.caseInsensitiveKeys {
+			next, exists = lookupFold(v, el.key)
+		}
+		return next, exists
+	case []interface{}:
+		if !el.isIndex {
+			return nil, false
+		}
+		i := el.index
+		if i < 0 {
+			i += len(v)
+		}
+		if i < 0 || i >= len(v) {
+			return nil, false
+		}
+		return v[i], true
+	case reflect.Value:
+		return fetchReflect(o, v, el)
+	}
+	if data == nil || isScalar(data) {
+		return nil, false
+	}
+	return fetchReflect(o, reflect.ValueOf(data), el)
+}
+
+// wildcardItems returns elements of a list or values of a map (or struct fields) ordered by key.
+func wildcardItems(data interface{}) []interface{} {
+	switch v := data.(type) {
+	case []interface{}:
+		return v
+	case map[string]interface{}:
+		keys := sortedKeys(v)
+		items := make([]interface{}, len(keys))
+		for i, key := range keys {
+			items[i] = v[key]
+		}
+		return items
+	case reflect.Value:
+		return reflectItems(v)
+	}
+	if data == nil || isScalar(data) {
+		return nil
+	}
+	return reflectItems(reflect.ValueOf(data))
+}
+
+// sortedKeys returns keys of the map in sort order.
+// Query maps are evaluated in this order so results and errors do not depend on map iteration order.
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// lookupFold finds key in a map ignoring case (Unicode case folding).
+// If more keys match, the first in sort order wins so results do not depend on map iteration order.
+func lookupFold(data map[string]interface{}, key string) (interface{}, bool) {
+	var (
+		matchedKey string
+		exists     bool
+	)
+	for k := range data {
+		if strings.EqualFold(k, key) && (!exists || k < matchedKey) {
+			matchedKey, exists = k, true
+		}
+	}
+	return data[matchedKey], exists
+}
@@ -0,0 +1,43 @@
+package gjsonquery_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/szpakas/gjsonquery"
+)
+
+func TestJoinPath(t *testing.T) {
+	type testCase struct {
+		symbol   string
+		keys     []string
+		expected string
+	}
+
+	var tests = []testCase{
+		{"aa", []string{"a"}, `a`},
+		{"ab", []string{"a", "b", "0"}, `a.b.0`},
+		{"ac", []string{"labels", "k8s.io/name"}, `labels.k8s\.io/name`},
+		{"ad", []string{"a\\b", "c"}, `a\\b.c`},
+		{"ae", []string{"items", "*"}, `items.\*`},
+		{"af", []string{"a*", "..."}, `a*.\.\.\.`},
+		{"ag", []string{""}, ``},
+	}
+
+	for _, tc := range tests {
+		if have := JoinPath(tc.keys...); have != tc.expected {
+			t.Errorf("[%s] Mismatch => expected: %q, have: %q", tc.symbol, tc.expected, have)
+		}
+	}
+}
+
+func TestJoinPathRoundTrip(t *testing.T) {
+	keys := []string{"k8s.io/name", "*", "a\\b", ".", "\\", "x.\\*", ""}
+	for _, key := range keys {
+		data := map[string]interface{}{"root": map[string]interface{}{key: "found"}}
+		value, found := FetchValue(data, JoinPath("root", key))
+		if !found || !reflect.DeepEqual(value, "found") {
+			t.Errorf("[%q] Key not reachable via escaped path %q", key, JoinPath("root", key))
+		}
+	}
+}
@@ -0,0 +1,498 @@
+package gjsonquery
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Query is a compiled query.
+//
+// Query is validated once by Compile and evaluated by Match without re-parsing
+// comparator names or column paths. It is immutable after compilation and safe
+// for concurrent use from multiple goroutines.
+type Query struct {
+	root node
+	opts *options
+	// selector holds paths used by MatchJSON
+	selector *selector
+}
+
+// Compile validates query and converts it into a Query.
+// Query structure is the same as accepted by DoesMatch.
+// Malformed query is reported with *ValidationError.
+func Compile(query interface{}, opts ...Option) (*Query, error) {
+	return compile(newOptions(opts), query)
+}
+
+func compile(o *options, query interface{}) (*Query, error) {
+	// first level match is always AND
+	root, err := compileAnd(o, query, "")
+	if err != nil {
+		return nil, err
+	}
+	if o.statistics {
+		collectStatistics(root)
+	}
+	q := &Query{root: root, opts: o, selector: newSelector(o, query)}
+	if o.optimize {
+		q = q.Optimize()
+	}
+	return q, nil
+}
+
+// Match reports whether data matches the compiled query.
+func (q *Query) Match(data map[string]interface{}) (bool, error) {
+	return q.root.match(q.opts, data)
+}
+
+// MatchWithTracer works as Match and reports evaluation steps to tracer.
+// It allows tracing of a single evaluation without affecting other users of the Query.
+func (q *Query) MatchWithTracer(data map[string]interface{}, tracer Tracer) (bool, error) {
+	o := *q.opts
+	o.tracer = tracer
+	return q.root.match(&o, data)
+}
+
+// -- nodes
+
+type node interface {
+	match(o *options, data interface{}) (bool, error)
+	// explain works as match and reports evaluation of the node, nodes are only described when skip is set
+	explain(o *options, data interface{}, skip bool) (*Explanation, error)
+}
+
+// andNode matches when all children match.
+type andNode struct {
+	children []node
+	// stats of children, set by WithStatistics
+	stats []*nodeStats
+}
+
+func (n *andNode) match(o *options, data interface{}) (bool, error) {
+	for i, child := range n.children {
+		matched, err := child.match(o, data)
+		if err != nil {
+			return false, err
+		}
+		if n.stats != nil {
+			n.stats[i].record(matched)
+		}
+		if !matched {
+			// first mismatch determine result -> no match
+			return false, nil
+		}
+	}
+	// defaults to match if nothing failed first
+	return true, nil
+}
+
+// orNode matches when at least one child matches.
+type orNode struct {
+	children []node
+	// stats of children, set by WithStatistics
+	stats []*nodeStats
+}
+
+func (n *orNode) match(o *options, data interface{}) (bool, error) {
+	for i, child := range n.children {
+		matched, err := child.match(o, data)
+		if err != nil {
+			return false, err
+		}
+		if n.stats != nil {
+			n.stats[i].record(matched)
+		}
+		if matched {
+			// one passed match is enough
+			return true, nil
+		}
+	}
+	// defaults to NO match if nothing matched first
+	return false, nil
+}
+
+// notNode negates result of the child.
+type notNode struct {
+	child node
+}
+
+func (n *notNode) match(o *options, data interface{}) (bool, error) {
+	matched, err := n.child.match(o, data)
+	if err != nil {
+		return false, err
+	}
+	return !matched, nil
+}
+
+// columnNode compares value fetched from data with all checks (implicit AND).
+type columnNode struct {
+	column string
+	path   []pathElement
+	// multi is set for wildcard paths, checks are then matched against all values found (see matchAny)
+	multi  bool
+	checks []check
+}
+
+func (n *columnNode) match(o *options, data interface{}) (matched bool, err error) {
+	if o.tracer != nil {
+		defer func() {
+			o.trace(TraceEvent{Op: TRACE_MATCH_VALUE, Column: n.column, Result: matched, Err: err})
+		}()
+	}
+
+	valueInData, existsInData := fetchPath(o, data, n.path)
+	if o.tracer != nil {
+		o.trace(TraceEvent{Op: TRACE_FETCH_VALUE, Column: n.column, Value: displayValue(valueInData), Found: existsInData})
+	}
+	candidates, _ := valueInData.([]interface{})
+	for i := range n.checks {
+		var (
+			matched bool
+			err     error
+		)
+		if n.multi {
+			matched, err = n.checks[i].matchAny(o, candidates)
+		} else {
+			matched, err = n.checks[i].match(o, valueInData, existsInData)
+		}
+		if err != nil {
+			return false, errorWithColumn(err, n.column)
+		}
+		if !matched {
+			// first mismatch determine result -> no match
+			return false, nil
+		}
+	}
+	// defaults to match if nothing failed first
+	return true, nil
+}
+
+// comparatorNode applies comparator directly to the data (comparator used in place of column).
+type comparatorNode struct {
+	check check
+}
+
+func (n *comparatorNode) match(o *options, data interface{}) (bool, error) {
+	return n.check.match(o, wholeData(data), true)
+}
+
+// check is a single comparator with its expectation.
+// "$not" is compiled into negated "$is"/"$in" so cmp.cType is never COMPARATOR_NOT.
+type check struct {
+	cmp comparator
+	// name is the comparator name as used in query
+	name        string
+	expectation interface{}
+	// set is prepared for "$in"
+	set *valueSet
+	// regex is compiled once for "$regex"
+	regex *regexp.Regexp
+}
+
+func (c *check) match(o *options, valueInData interface{}, existsInData bool) (matched bool, err error) {
+	if o.tracer != nil {
+		defer func() {
+			c.trace(o, valueInData, existsInData, matched, err)
+		}()
+	}
+
+	matched, err = c.matchPositive(o, valueInData, existsInData)
+	if err != nil {
+		return false, err
+	}
+	return matched != c.cmp.negated, nil
+}
+
+// matchAny mirrors matchAny function for wildcard paths.
+func (c *check) matchAny(o *options, candidates []interface{}) (matched bool, err error) {
+	if c.cmp.cType == COMPARATOR_EXISTS {
+		return c.match(o, nil, len(candidates) > 0)
+	}
+	if o.tracer != nil {
+		defer func() {
+			c.trace(o, candidates, len(candidates) > 0, matched, err)
+		}()
+	}
+
+	for _, candidate := range candidates {
+		matched, err := c.matchPositive(o, candidate, true)
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			return !c.cmp.negated, nil
+		}
+	}
+	return c.cmp.negated, nil
+}
+
+// trace reports the check with comparator name as used in query and the result after negation.
+func (c *check) trace(o *options, valueInData interface{}, existsInData bool, matched bool, err error) {
+	o.trace(TraceEvent{Op: TRACE_MATCH_COMPARATOR, Comparator: c.name, Value: displayValue(valueInData), Found: existsInData, Expectation: c.expectation, Result: matched, Err: err})
+}
+
+// matchPositive compares value ignoring negation of the comparator.
+// Events are reported by match and matchAny, so the comparator is evaluated without tracer.
+func (c *check) matchPositive(o *options, valueInData interface{}, existsInData bool) (bool, error) {
+	if o.tracer != nil {
+		quiet := *o
+		quiet.tracer = nil
+		o = &quiet
+	}
+	if c.set != nil || c.regex != nil {
+		var err error
+		if valueInData, err = plainValue(valueInData); err != nil {
+			return false, errorWithComparator(err, c.name)
+		}
+	}
+	if c.set != nil {
+		return c.set.contains(valueInData), nil
+	}
+	if c.regex != nil {
+		matched, err := matchRegex(c.regex, valueInData)
+		return matched, errorWithComparator(err, c.name)
+	}
+	matched, err := matchComparator(o, comparator{cType: c.cmp.cType}, valueInData, existsInData, c.expectation)
+	return matched, errorWithComparator(err, c.name)
+}
+
+// valueSet is a pre-built lookup for list expectations.
+// Values which can be used as map keys are kept in a map, the rest is scanned linearly.
+type valueSet struct {
+	scalars map[interface{}]struct{}
+	others  []interface{}
+	strict  bool
+}
+
+func newValueSet(list []interface{}, strict bool) *valueSet {
+	s := &valueSet{scalars: make(map[interface{}]struct{}, len(list)), strict: strict}
+	for _, e := range list {
+		// Go values are looked up in the form of values fetched from data
+		e = comparableValue(e)
+		if isScalar(e) {
+			s.scalars[s.key(e)] = struct{}{}
+		} else {
+			s.others = append(s.others, e)
+		}
+	}
+	return s
+}
+
+func (s *valueSet) contains(v interface{}) bool {
+	if isScalar(v) {
+		_, ok := s.scalars[s.key(v)]
+		return ok
+	}
+	for _, e := range s.others {
+		if equal(e, v, s.strict) {
+			return true
+		}
+	}
+	return false
+}
+
+// key maps all numbers with the same value to the same key unless strict.
+func (s *valueSet) key(v interface{}) interface{} {
+	if !s.strict {
+		if n, ok := toNumber(v); ok {
+			return n.key()
+		}
+	}
+	return v
+}
+
+// -- compilation
+
+// compileAnd mirrors matcherAnd, pointer is a JSON Pointer to query within the root query.
+func compileAnd(o *options, query interface{}, pointer string) (node, error) {
+	switch v := interface{}(query).(type) {
+	// -- query is a key->value map
+	case map[string]interface{}:
+		n := &andNode{children: make([]node, 0, len(v))}
+		for _, column := range sortedKeys(v) {
+			child, err := compileValue(o, column, v[column], pointerAppend(pointer, column))
+			if err != nil {
+				return nil, err
+			}
+			n.children = append(n.children, child)
+		}
+		return n, nil
+	// -- query is a list
+	case []interface{}:
+		n := &andNode{children: make([]node, 0, len(v))}
+		for i, expectedValue := range v {
+			// list match is always and
+			child, err := compileAnd(o, expectedValue, pointerAppend(pointer, strconv.Itoa(i)))
+			if err != nil {
+				return nil, err
+			}
+			n.children = append(n.children, child)
+		}
+		return n, nil
+	// -- query built with Field, And, Or and Not
+	case Expr:
+		return compileAnd(o, v.Query(), pointer)
+	}
+
+	// unknown type
+	return nil, &ValidationError{Path: pointer, Err: newQueryError("matcherAnd", ErrUnknownQueryType, query)}
+}
+
+// compileOr mirrors matcherOr.
+func compileOr(o *options, query interface{}, pointer string) (node, error) {
+	switch v := interface{}(query).(type) {
+	// -- query is a key->value map
+	case map[string]interface{}:
+		n := &orNode{children: make([]node, 0, len(v))}
+		for _, column := range sortedKeys(v) {
+			child, err := compileValue(o, column, v[column], pointerAppend(pointer, column))
+			if err != nil {
+				return nil, err
+			}
+			n.children = append(n.children, child)
+		}
+		return n, nil
+	// -- query is a list
+	case []interface{}:
+		n := &orNode{children: make([]node, 0, len(v))}
+		for i, expectedValue := range v {
+			// list match is always and
+			child, err := compileAnd(o, expectedValue, pointerAppend(pointer, strconv.Itoa(i)))
+			if err != nil {
+				return nil, err
+			}
+			n.children = append(n.children, child)
+		}
+		return n, nil
+	// -- query built with Field, And, Or and Not
+	case Expr:
+		return compileOr(o, v.Query(), pointer)
+	}
+
+	// unknown type
+	return nil, &ValidationError{Path: pointer, Err: newQueryError("matcherOr", ErrUnknownQueryType, query)}
+}
+
+// compileValue mirrors matchValue, pointer points to the expectation.
+func compileValue(o *options, column string, expectation interface{}, pointer string) (node, error) {
+	// -- direct detection based on column
+	if strings.HasPrefix(column, "!") {
+		child, err := compileValue(o, column[1:], expectation, pointer)
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{child: child}, nil
+	}
+
+	switch column {
+	case "$and":
+		return compileAnd(o, expectation, pointer)
+	case "$or":
+		return compileOr(o, expectation, pointer)
+	case "$not":
+		child, err := compileAnd(o, expectation, pointer)
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{child: child}, nil
+	}
+
+	// direct comparator which is not matcher
+	if strings.HasPrefix(column, "$") {
+		c, err := compileCheck(o, detectComparator(o, column), column, expectation, pointer)
+		if err != nil {
+			return nil, err
+		}
+		return &comparatorNode{check: c}, nil
+	}
+
+	path, err := splitColumn(o, column)
+	if err != nil {
+		return nil, &ValidationError{Path: pointer, Err: errorWithColumn(err, column)}
+	}
+	n := &columnNode{column: column, path: path, multi: hasWildcard(path)}
+
+	// -- if still undetermined fall-back to expectation type based detection
+	switch interface{}(expectation).(type) {
+	// -- expectation is a list -> wrap in "$in" comparator
+	case []interface{}:
+		c, err := compileCheck(o, comparator{cType: COMPARATOR_IN}, "$in", expectation, pointer)
+		if err != nil {
+			return nil, &ValidationError{Path: pointer, Err: errorWithColumn(err.Err, column)}
+		}
+		n.checks = []check{c}
+		return n, nil
+	default:
+		// -- expectation is logical scalar (not list/slice, nor map), null included
+		if isScalar(expectation) {
+			n.checks = []check{{cmp: comparator{cType: COMPARATOR_IS}, name: "$is", expectation: expectation}}
+			return n, nil
+		}
+	}
+
+	// -- still undetermined -> pull comparators/expectations from expectation
+	expectationAsMap, ok := expectation.(map[string]interface{})
+	if !ok {
+		return nil, &ValidationError{Path: pointer, Err: errorWithColumn(newQueryError("matchValue", ErrNotAMap, expectation), column)}
+	}
+
+	// multiple comparators on one level are joined with implicit AND
+	n.checks = make([]check, 0, len(expectationAsMap))
+	for _, expKey := range sortedKeys(expectationAsMap) {
+		c, err := compileCheck(o, detectComparator(o, expKey), expKey, expectationAsMap[expKey], pointerAppend(pointer, expKey))
+		if err != nil {
+			return nil, &ValidationError{Path: err.Path, Err: errorWithColumn(err.Err, column)}
+		}
+		n.checks = append(n.checks, c)
+	}
+	return n, nil
+}
+
+// compileCheck validates expectation against comparator.
+// Only errors which depend solely on the query are reported, data dependent errors are left for Match.
+func compileCheck(o *options, cmp comparator, name string, expectation interface{}, pointer string) (check, *ValidationError) {
+	c := check{cmp: cmp, name: name, expectation: expectation}
+	invalid := func(err error) *ValidationError {
+		return &ValidationError{Path: pointer, Err: errorWithComparator(err, name)}
+	}
+
+	switch cmp.cType {
+	case COMPARATOR_IN:
+		list, ok := expectation.([]interface{})
+		if !ok {
+			return c, invalid(newQueryError("comparatorIn", ErrUnknownExpectedType, expectation))
+		}
+		c.set = newValueSet(list, o.strictNumbers)
+	case COMPARATOR_NOT:
+		c.cmp = comparator{cType: COMPARATOR_IS, negated: !cmp.negated}
+		if list, ok := expectation.([]interface{}); ok {
+			c.cmp.cType = COMPARATOR_IN
+			c.set = newValueSet(list, o.strictNumbers)
+		}
+	case COMPARATOR_GT, COMPARATOR_GTE, COMPARATOR_LT, COMPARATOR_LTE:
+		// expectation compared with itself is checked the same way as against any value
+		if _, err := comparatorGeneric(cmp.cType, expectation, expectation); err != nil {
+			return c, invalid(err)
+		}
+	case COMPARATOR_EXISTS:
+		if _, ok := expectation.(bool); !ok {
+			return c, invalid(newQueryError("comparatorExists", ErrUnknownExpectedType, expectation))
+		}
+	case COMPARATOR_REGEX:
+		re, err := compileRegex(expectation)
+		if err != nil {
+			return c, invalid(err)
+		}
+		c.regex = re
+	case COMPARATOR_STARTS_WITH, COMPARATOR_ENDS_WITH, COMPARATOR_IIS:
+		if _, ok := expectation.(string); !ok {
+			return c, invalid(newQueryError("comparatorString", ErrUnknownExpectedType, expectation))
+		}
+	case COMPARATOR_IS, COMPARATOR_CONTAINS:
+	default:
+		return c, invalid(newQueryError("matchComparator", ErrUnknownComparator, nil))
+	}
+
+	return c, nil
+}
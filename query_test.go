@@ -0,0 +1,139 @@
+package gjsonquery_test
+
+import (
+	"sync"
+	"testing"
+
+	. "github.com/szpakas/gjsonquery"
+)
+
+func TestQueryMatch(t *testing.T) {
+	for _, tDef := range doesMatchTests {
+		q, err := Compile(tDef.query)
+		if err != nil {
+			// query errors are reported up front -> every case has to expect the same error
+			for _, tCase := range tDef.tests {
+				if !sameError(tCase.err, err) {
+					t.Errorf("[%s|%s] Mismatch on compile error => expected: %#+v, have: %#+v", tDef.symbol, tCase.symbol, tCase.err, err)
+				}
+			}
+			continue
+		}
+
+		for _, tCase := range tDef.tests {
+			result, err := q.Match(tCase.data)
+
+			if !sameError(tCase.err, err) {
+				t.Errorf("[%s|%s] Mismatch on error => expected: %#+v, have: %#+v", tDef.symbol, tCase.symbol, tCase.err, err)
+			}
+
+			if result != tCase.expected {
+				t.Errorf("[%s|%s] Mismatch => expected: %#+v, have: %#+v", tDef.symbol, tCase.symbol, tCase.expected, result)
+			}
+		}
+	}
+}
+
+func TestQueryMatchStrictNumbers(t *testing.T) {
+	var tests = []struct {
+		symbol   string
+		query    interface{}
+		data     map[string]interface{}
+		expected bool
+	}{
+		{"aa", map[string]interface{}{"a": 100}, map[string]interface{}{"a": 100}, true},
+		{"ab", map[string]interface{}{"a": 100}, map[string]interface{}{"a": 100.0}, false},
+		{"ac", map[string]interface{}{"a": 100.0}, map[string]interface{}{"a": 100}, false},
+		{"ad", map[string]interface{}{"a": 100}, map[string]interface{}{"a": int64(100)}, false},
+		{"ba", map[string]interface{}{"a": []interface{}{100, 101}}, map[string]interface{}{"a": 101}, true},
+		{"bb", map[string]interface{}{"a": []interface{}{100, 101}}, map[string]interface{}{"a": 101.0}, false},
+		{"ca", map[string]interface{}{"a": map[string]interface{}{"!$not": 100}}, map[string]interface{}{"a": 100.0}, false},
+		{"cb", map[string]interface{}{"a": map[string]interface{}{"!$not": []interface{}{100}}}, map[string]interface{}{"a": 100.0}, false},
+		{"da", map[string]interface{}{"a": map[string]interface{}{"$contains": 100}}, map[string]interface{}{"a": []interface{}{100.0}}, false},
+	}
+
+	for _, tc := range tests {
+		for _, strict := range []bool{true, false} {
+			q, err := Compile(tc.query, WithStrictNumbers(strict))
+			if err != nil {
+				t.Fatalf("[%s] Compile failed: %s", tc.symbol, err)
+			}
+			// loose mode matches whenever values are equal
+			expected := tc.expected || !strict
+
+			matched, err := q.Match(tc.data)
+			if err != nil || matched != expected {
+				t.Errorf("[%s|strict: %t] Mismatch => expected: %t, have: %t (err: %v)", tc.symbol, strict, expected, matched, err)
+			}
+		}
+	}
+}
+
+func TestCompile(t *testing.T) {
+	var tests = []struct {
+		symbol string
+		query  interface{}
+		err    error
+	}{
+		{"aa", map[string]interface{}{"a": 100}, nil},
+		{"ab", map[string]interface{}{"a": map[string]interface{}{"$gte": 18, "$lt": 65}}, nil},
+		{"ac", []interface{}{map[string]interface{}{"a": []interface{}{1, 2}}}, nil},
+		// errors in branches which would not be reached during matching
+		{"ba", map[string]interface{}{
+			"$or": []interface{}{
+				map[string]interface{}{"a": 1},
+				map[string]interface{}{"b": map[string]interface{}{"$bogus": 1}},
+			},
+		}, ErrUnknownComparator},
+		{"bb", map[string]interface{}{"a": map[string]interface{}{"$in": 101}}, ErrUnknownExpectedType},
+		{"bc", map[string]interface{}{"a": map[string]interface{}{"$lt": true}}, ErrUnknownExpectedType},
+		{"bd", map[string]interface{}{"$and": 101}, ErrUnknownQueryType},
+		{"be", "a", ErrUnknownQueryType},
+		{"bf", map[string]interface{}{"a": map[string]interface{}{"!$regex": "[a-"}}, ErrInvalidPattern},
+		{"bg", map[string]interface{}{"a": map[string]interface{}{"$regex": []interface{}{"a"}}}, ErrUnknownExpectedType},
+	}
+
+	for _, tc := range tests {
+		q, err := Compile(tc.query)
+		if !sameError(tc.err, err) {
+			t.Errorf("[%s] Mismatch on error => expected: %#+v, have: %#+v", tc.symbol, tc.err, err)
+		}
+		if (err == nil) != (q != nil) {
+			t.Errorf("[%s] Query should be returned only on success, have: %#+v", tc.symbol, q)
+		}
+	}
+}
+
+func TestQueryMatchConcurrent(t *testing.T) {
+	q, err := Compile(map[string]interface{}{
+		"a.b": []interface{}{1, 2, 3},
+		"$or": []interface{}{
+			map[string]interface{}{"c": map[string]interface{}{"$gt": 10}},
+			map[string]interface{}{"d": "x"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Compile failed: %s", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				data := map[string]interface{}{
+					"a": map[string]interface{}{"b": j % 5},
+					"c": i + j%20,
+				}
+				expected := j%5 >= 1 && j%5 <= 3 && i+j%20 > 10
+				matched, err := q.Match(data)
+				if err != nil || matched != expected {
+					t.Errorf("[%d|%d] Mismatch => expected: %t, have: %t (err: %v)", i, j, expected, matched, err)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,523 @@
+package gjsonquery
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DoesMatchValue reports whether Go value v matches the query.
+//
+// Columns are resolved on v the same way as on v marshalled with encoding/json:
+// struct fields are named by json tags (fields tagged "-" and empty "omitempty" fields are missing),
+// fields of embedded structs are promoted, pointers and interfaces are followed,
+// maps with string keys and slices are traversed as JSON objects and lists.
+// Values implementing json.Marshaler or encoding.TextMarshaler are used in their marshalled form.
+// Only values compared by the query are converted, signed integers become int64, unsigned uint64 and floats float64,
+// so numbers are compared exactly (see WithStrictNumbers for comparison of Go types).
+// Values referencing themselves are reported with ErrCyclicValue when they have to be compared as a whole.
+func DoesMatchValue(query interface{}, v interface{}) (bool, error) {
+	return defaultMatcher.DoesMatchValue(query, v)
+}
+
+// DoesMatchValue reports whether Go value v matches the query, see DoesMatchValue.
+func (m *Matcher) DoesMatchValue(query interface{}, v interface{}) (bool, error) {
+	// first level match is always AND
+	return matcherAnd(m.opts, query, v)
+}
+
+// MatchValue reports whether Go value v matches the compiled query, see DoesMatchValue.
+func (q *Query) MatchValue(v interface{}) (bool, error) {
+	return q.root.match(q.opts, v)
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// isPlain reports whether v is of a type used by encoding/json (maps, lists and scalars).
+func isPlain(v interface{}) bool {
+	switch v.(type) {
+	case nil, bool, string, json.Number, *big.Int,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64,
+		float32, float64,
+		map[string]interface{}, []interface{}:
+		return true
+	}
+	return false
+}
+
+// plainValue converts value fetched from data into types used by encoding/json (maps, lists and scalars).
+// Values of these types are returned as they are, Go values referencing themselves are reported with ErrCyclicValue.
+func plainValue(v interface{}) (interface{}, error) {
+	if isPlain(v) {
+		return v, nil
+	}
+	rv, ok := v.(reflect.Value)
+	if !ok {
+		rv = reflect.ValueOf(v)
+	}
+	return (&plainConverter{}).convert(rv)
+}
+
+// lazyValue converts only scalars fetched from data, other Go values are converted by comparators (see plainValue),
+// so values which are not compared (e.g. by "$exists") are never walked.
+func lazyValue(v interface{}) interface{} {
+	if isPlain(v) {
+		return v
+	}
+	rv, ok := v.(reflect.Value)
+	if !ok {
+		rv = reflect.ValueOf(v)
+	}
+	rv = indirect(rv)
+	if !rv.IsValid() {
+		return nil
+	}
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Struct:
+		return rv
+	}
+	// scalars can not reference themselves
+	plain, _ := (&plainConverter{}).convert(rv)
+	return plain
+}
+
+// displayValue converts value fetched from data for tracing and explanations, cyclic values are shown as nil.
+func displayValue(v interface{}) interface{} {
+	if list, ok := v.([]interface{}); ok && list != nil {
+		// values fetched with wildcard
+		out := make([]interface{}, len(list))
+		for i, item := range list {
+			out[i], _ = plainValue(item)
+		}
+		return out
+	}
+	plain, _ := plainValue(v)
+	return plain
+}
+
+// startDetectingCyclesAfter is the nesting depth from which visited pointers are tracked, as in encoding/json.
+const startDetectingCyclesAfter = 1000
+
+// plainConverter converts Go values with reflection, see plainValue.
+type plainConverter struct {
+	depth int
+	// seen holds pointers (maps, slices) on the current path once depth exceeds startDetectingCyclesAfter
+	seen map[interface{}]struct{}
+}
+
+// cycleKey identifies value which can reference itself, the same way as encoding/json does.
+type cycleKey struct {
+	ptr uintptr
+	len int
+}
+
+func (c *plainConverter) convert(rv reflect.Value) (interface{}, error) {
+	c.depth++
+	defer func() { c.depth-- }()
+
+	if c.depth > startDetectingCyclesAfter {
+		switch rv.Kind() {
+		case reflect.Ptr, reflect.Map, reflect.Slice:
+			if !rv.IsNil() {
+				key := cycleKey{ptr: rv.Pointer()}
+				if rv.Kind() == reflect.Slice {
+					key.len = rv.Len()
+				}
+				if c.seen == nil {
+					c.seen = make(map[interface{}]struct{})
+				}
+				if _, ok := c.seen[key]; ok {
+					var value interface{}
+					if rv.CanInterface() {
+						value = rv.Interface()
+					}
+					return nil, newQueryError("plainValue", ErrCyclicValue, value)
+				}
+				c.seen[key] = struct{}{}
+				defer delete(c.seen, key)
+			}
+		}
+	}
+
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		return c.convert(rv.Elem())
+	}
+	if !rv.IsValid() {
+		return nil, nil
+	}
+	if marshalled, ok := marshalPlain(rv); ok {
+		return marshalled, nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint(), nil
+	case reflect.Float32:
+		// shortest representation, as written by encoding/json
+		f, _ := strconv.ParseFloat(strconv.FormatFloat(rv.Float(), 'g', -1, 32), 64)
+		return f, nil
+	case reflect.Float64:
+		return rv.Float(), nil
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Slice:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return base64.StdEncoding.EncodeToString(rv.Bytes()), nil
+		}
+		fallthrough
+	case reflect.Array:
+		out := make([]interface{}, rv.Len())
+		for i := range out {
+			item, err := c.convert(rv.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			out[i] = item
+		}
+		return out, nil
+	case reflect.Map:
+		if rv.IsNil() || rv.Type().Key().Kind() != reflect.String {
+			return nil, nil
+		}
+		out := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			item, err := c.convert(iter.Value())
+			if err != nil {
+				return nil, err
+			}
+			out[iter.Key().String()] = item
+		}
+		return out, nil
+	case reflect.Struct:
+		out := make(map[string]interface{})
+		for _, f := range structFields(rv.Type()) {
+			if fv, ok := f.value(rv); ok {
+				item, err := c.convert(fv)
+				if err != nil {
+					return nil, err
+				}
+				out[f.name] = item
+			}
+		}
+		return out, nil
+	}
+	// channels, functions and complex numbers have no JSON form
+	return nil, nil
+}
+
+// fetchReflect descends one level into Go value.
+func fetchReflect(o *options, rv reflect.Value, el pathElement) (interface{}, bool) {
+	rv = indirect(rv)
+	if !rv.IsValid() {
+		return nil, false
+	}
+	if marshalled, ok := marshalPlain(rv); ok {
+		return fetchElement(o, marshalled, el)
+	}
+
+	switch rv.Kind() {
+	case reflect.Map:
+		keyType := rv.Type().Key()
+		if keyType.Kind() != reflect.String {
+			return nil, false
+		}
+		if next := rv.MapIndex(reflect.ValueOf(el.key).Convert(keyType)); next.IsValid() {
+			return next, true
+		}
+		if o.caseInsensitiveKeys {
+			var matched reflect.Value
+			iter := rv.MapRange()
+			for iter.Next() {
+				k := iter.Key().String()
+				if strings.EqualFold(k, el.key) && (!matched.IsValid() || k < matched.String()) {
+					matched = iter.Key()
+				}
+			}
+			if matched.IsValid() {
+				return rv.MapIndex(matched), true
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		// []byte is a string in JSON
+		if !el.isIndex || (rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8) {
+			return nil, false
+		}
+		i := el.index
+		if i < 0 {
+			i += rv.Len()
+		}
+		if i < 0 || i >= rv.Len() {
+			return nil, false
+		}
+		return rv.Index(i), true
+	case reflect.Struct:
+		fields := structFields(rv.Type())
+		for _, f := range fields {
+			if f.name == el.key {
+				return f.fetch(rv)
+			}
+		}
+		if o.caseInsensitiveKeys {
+			// fields are sorted by name so the first match wins as in lookupFold
+			for _, f := range fields {
+				if strings.EqualFold(f.name, el.key) {
+					return f.fetch(rv)
+				}
+			}
+		}
+	}
+	return nil, false
+}
+
+// reflectItems returns elements of a list, values of a map ordered by key or struct fields ordered by name.
+func reflectItems(rv reflect.Value) []interface{} {
+	rv = indirect(rv)
+	if !rv.IsValid() {
+		return nil
+	}
+	if marshalled, ok := marshalPlain(rv); ok {
+		return wildcardItems(marshalled)
+	}
+
+	var items []interface{}
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+			return nil
+		}
+		for i := 0; i < rv.Len(); i++ {
+			items = append(items, rv.Index(i))
+		}
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, key := range keys {
+			items = append(items, rv.MapIndex(key))
+		}
+	case reflect.Struct:
+		for _, f := range structFields(rv.Type()) {
+			if fv, ok := f.value(rv); ok {
+				items = append(items, fv)
+			}
+		}
+	}
+	return items
+}
+
+// indirect follows pointers and interfaces, nil results in invalid value.
+func indirect(rv reflect.Value) reflect.Value {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return reflect.Value{}
+		}
+		rv = rv.Elem()
+	}
+	return rv
+}
+
+// marshalPlain converts values with custom JSON form (json.Marshaler, encoding.TextMarshaler) into plain value.
+func marshalPlain(rv reflect.Value) (interface{}, bool) {
+	if !rv.CanInterface() {
+		return nil, false
+	}
+	if !isMarshaler(rv.Type()) {
+		// methods with pointer receiver are used for addressable values, the same as encoding/json does
+		if !rv.CanAddr() || !isMarshaler(reflect.PtrTo(rv.Type())) {
+			return nil, false
+		}
+		rv = rv.Addr()
+	}
+
+	raw, err := json.Marshal(rv.Interface())
+	if err != nil {
+		return nil, true
+	}
+	var out interface{}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, true
+	}
+	return out, true
+}
+
+func isMarshaler(t reflect.Type) bool {
+	return t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType)
+}
+
+// -- struct fields
+
+// structField is a field visible in JSON form of a struct, possibly promoted from embedded struct.
+type structField struct {
+	name      string
+	index     []int
+	tagged    bool
+	omitEmpty bool
+	omitZero  bool
+}
+
+// fieldCache maps reflect.Type to []structField.
+var fieldCache sync.Map
+
+// structFields returns fields of struct type t ordered by name, following encoding/json rules.
+func structFields(t reflect.Type) []structField {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.([]structField)
+	}
+
+	type queued struct {
+		typ   reflect.Type
+		index []int
+	}
+
+	var (
+		fields  []structField
+		current []queued
+		next    = []queued{{typ: t}}
+		visited = map[reflect.Type]bool{}
+	)
+	for len(next) > 0 {
+		current, next = next, nil
+		level := map[reflect.Type]bool{}
+
+		for _, q := range current {
+			if visited[q.typ] {
+				continue
+			}
+			level[q.typ] = true
+
+			for i := 0; i < q.typ.NumField(); i++ {
+				sf := q.typ.Field(i)
+				ft := sf.Type
+				if ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				if sf.Anonymous {
+					// fields of unexported embedded structs are still promoted
+					if !sf.IsExported() && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !sf.IsExported() {
+					continue
+				}
+
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, opts, _ := strings.Cut(tag, ",")
+				index := append(append([]int(nil), q.index...), i)
+
+				if name == "" && sf.Anonymous && ft.Kind() == reflect.Struct {
+					next = append(next, queued{typ: ft, index: index})
+					continue
+				}
+				f := structField{name: name, index: index, tagged: name != ""}
+				if name == "" {
+					f.name = sf.Name
+				}
+				for _, opt := range strings.Split(opts, ",") {
+					f.omitEmpty = f.omitEmpty || opt == "omitempty"
+					f.omitZero = f.omitZero || opt == "omitzero"
+				}
+				fields = append(fields, f)
+			}
+		}
+		for typ := range level {
+			visited[typ] = true
+		}
+	}
+
+	// the shallowest field wins, tagged one if there are more on the same depth, otherwise the name is ambiguous
+	sort.SliceStable(fields, func(i, j int) bool {
+		if fields[i].name != fields[j].name {
+			return fields[i].name < fields[j].name
+		}
+		if len(fields[i].index) != len(fields[j].index) {
+			return len(fields[i].index) < len(fields[j].index)
+		}
+		return fields[i].tagged && !fields[j].tagged
+	})
+	out := make([]structField, 0, len(fields))
+	for i := 0; i < len(fields); {
+		j := i + 1
+		for j < len(fields) && fields[j].name == fields[i].name {
+			j++
+		}
+		if j == i+1 || len(fields[i+1].index) > len(fields[i].index) || fields[i].tagged != fields[i+1].tagged {
+			out = append(out, fields[i])
+		}
+		i = j
+	}
+
+	cached, _ := fieldCache.LoadOrStore(t, out)
+	return cached.([]structField)
+}
+
+// value returns field of struct value rv, false when it is missing in JSON form.
+func (f structField) value(rv reflect.Value) (reflect.Value, bool) {
+	for i, x := range f.index {
+		if i > 0 && rv.Kind() == reflect.Ptr {
+			// field promoted through nil embedded pointer
+			if rv.IsNil() {
+				return reflect.Value{}, false
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+	if (f.omitEmpty && isEmptyValue(rv)) || (f.omitZero && rv.IsZero()) {
+		return reflect.Value{}, false
+	}
+	return rv, true
+}
+
+func (f structField) fetch(rv reflect.Value) (interface{}, bool) {
+	fv, ok := f.value(rv)
+	if !ok {
+		return nil, false
+	}
+	return fv, true
+}
+
+// isEmptyValue mirrors "omitempty" of encoding/json.
+func isEmptyValue(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Bool:
+		return !rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return rv.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return rv.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,265 @@
+package gjsonquery_test
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	. "github.com/szpakas/gjsonquery"
+)
+
+type reflectStatus string
+
+type reflectAddress struct {
+	City string `json:"city"`
+	Zip  string `json:"zip,omitempty"`
+}
+
+type reflectBase struct {
+	ID      int       `json:"id"`
+	Created time.Time `json:"created"`
+}
+
+type reflectAudit struct {
+	ID     string `json:"id"`
+	Author string `json:"author"`
+}
+
+type reflectExtra struct {
+	Author string `json:"author"`
+}
+
+type reflectUser struct {
+	reflectBase
+	*reflectAudit
+	Name    string            `json:"name"`
+	Nick    *string           `json:"nick"`
+	Age     uint8             // untagged -> Go name
+	Score   float32           `json:"score"`
+	Tags    []string          `json:"tags"`
+	Address *reflectAddress   `json:"address"`
+	Labels  map[string]string `json:"labels"`
+	Status  reflectStatus     `json:"status"`
+	Meta    interface{}       `json:"meta,omitempty"`
+	Others  []reflectAddress  `json:"others"`
+	Secret  string            `json:"-"`
+	Raw     []byte            `json:"raw"`
+	Ints    map[int]string    `json:"ints"`
+	Extra   reflectExtra      `json:"extra"`
+	private int
+	Scores  map[string]float64 `json:"scores"`
+}
+
+func TestDoesMatchValue(t *testing.T) {
+	nick := "bo"
+	user := &reflectUser{
+		reflectBase:  reflectBase{ID: 7, Created: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)},
+		reflectAudit: &reflectAudit{ID: "audit-1", Author: "admin"},
+		Name:         "Bob",
+		Nick:         &nick,
+		Age:          42,
+		Score:        0.1,
+		Tags:         []string{"new", "vip"},
+		Address:      &reflectAddress{City: "Lodz"},
+		Labels:       map[string]string{"k8s.io/name": "api"},
+		Status:       "active",
+		Others:       []reflectAddress{{City: "Krakow", Zip: "30-001"}, {City: "Gdansk"}},
+		Secret:       "xxx",
+		Raw:          []byte("hi"),
+		Ints:         map[int]string{1: "one"},
+		Scores:       map[string]float64{"b": 2, "a": 1},
+		private:      1,
+	}
+
+	var tests = []struct {
+		symbol   string
+		query    interface{}
+		expected bool
+	}{
+		// fields, tags and pointers
+		{"aa", map[string]interface{}{"name": "Bob"}, true},
+		{"ab", map[string]interface{}{"Name": map[string]interface{}{"$exists": true}}, false},
+		{"ac", map[string]interface{}{"nick": "bo"}, true},
+		{"ad", map[string]interface{}{"Age": map[string]interface{}{"$gte": 18}}, true},
+		{"ae", map[string]interface{}{"score": 0.1}, true},
+		{"af", map[string]interface{}{"status": "active"}, true},
+		{"ag", map[string]interface{}{"address.city": "Lodz"}, true},
+		{"ah", map[string]interface{}{"address.zip": map[string]interface{}{"$exists": false}}, true},
+		{"ai", map[string]interface{}{"Secret": map[string]interface{}{"$exists": false}, "private": map[string]interface{}{"$exists": false}}, true},
+		{"aj", map[string]interface{}{"meta": map[string]interface{}{"$exists": false}}, true},
+		// embedded structs: shallower field wins, equal depth without tag is ambiguous
+		{"ba", map[string]interface{}{"created": "2020-01-02T03:04:05Z"}, true},
+		{"bb", map[string]interface{}{"id": map[string]interface{}{"$exists": false}}, true},
+		{"bc", map[string]interface{}{"author": "admin"}, true},
+		{"bd", map[string]interface{}{"extra.author": map[string]interface{}{"$exists": false}}, false},
+		// slices and maps
+		{"ca", map[string]interface{}{"tags": map[string]interface{}{"$contains": "vip"}}, true},
+		{"cb", map[string]interface{}{"tags.-1": "vip"}, true},
+		{"cc", map[string]interface{}{"others.*.zip": "30-001"}, true},
+		{"cd", map[string]interface{}{"others.1.city": []interface{}{"Gdansk", "Sopot"}}, true},
+		{"ce", map[string]interface{}{`labels.k8s\.io/name`: "api"}, true},
+		{"cf", map[string]interface{}{"scores.*": map[string]interface{}{"$gt": 1.5}}, true},
+		{"cg", map[string]interface{}{"raw": "aGk="}, true},
+		{"ch", map[string]interface{}{"raw.0": map[string]interface{}{"$exists": false}}, true},
+		{"ci", map[string]interface{}{"ints": map[string]interface{}{"$exists": true}}, true},
+		{"cj", map[string]interface{}{"address": map[string]interface{}{"$is": nil}}, false},
+		// Go values in query are compared as Go values in data are
+		{"ck", map[string]interface{}{"address": map[string]interface{}{"$is": reflectAddress{City: "Lodz"}}}, true},
+		{"cl", map[string]interface{}{"address": map[string]interface{}{"$in": []interface{}{&reflectAddress{City: "Lodz"}}}}, true},
+		{"cm", map[string]interface{}{"others": map[string]interface{}{"$contains": reflectAddress{City: "Gdansk"}}}, true},
+		{"cn", map[string]interface{}{"others.0": map[string]interface{}{"$not": reflectAddress{City: "Krakow"}}}, true},
+	}
+
+	raw, err := json.Marshal(user)
+	if err != nil {
+		t.Fatalf("Marshal failed: %s", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %s", err)
+	}
+
+	for _, tc := range tests {
+		q, err := Compile(tc.query)
+		if err != nil {
+			t.Fatalf("[%s] Compile failed: %s", tc.symbol, err)
+		}
+
+		for name, matched := range map[string]func() (bool, error){
+			"DoesMatchValue":   func() (bool, error) { return DoesMatchValue(tc.query, user) },
+			"Query.MatchValue": func() (bool, error) { return q.MatchValue(*user) },
+			// value has to match as its JSON form does
+			"DoesMatch": func() (bool, error) { return DoesMatch(tc.query, decoded) },
+		} {
+			result, err := matched()
+			if err != nil || result != tc.expected {
+				t.Errorf("[%s|%s] Mismatch => expected: %t, have: %t (err: %v)", tc.symbol, name, tc.expected, result, err)
+			}
+		}
+	}
+}
+
+func TestDoesMatchValueNil(t *testing.T) {
+	var user *reflectUser
+	for _, v := range []interface{}{nil, user, 10, "a"} {
+		matched, err := DoesMatchValue(map[string]interface{}{"name": map[string]interface{}{"$exists": false}}, v)
+		if err != nil || !matched {
+			t.Errorf("[%#v] Mismatch => expected: true, have: %t (err: %v)", v, matched, err)
+		}
+	}
+}
+
+func TestDoesMatchValueCaseInsensitiveKeys(t *testing.T) {
+	m := NewMatcher(WithCaseInsensitiveKeys(true))
+	data := map[string]interface{}{
+		"user": &reflectUser{Name: "Bob", Labels: map[string]string{"Env": "prod"}},
+	}
+
+	matched, err := m.DoesMatchValue(map[string]interface{}{"USER.NAME": "Bob", "user.labels.env": "prod"}, data)
+	if err != nil || !matched {
+		t.Errorf("Mismatch => expected: true, have: %t (err: %v)", matched, err)
+	}
+}
+
+type reflectNode struct {
+	Name   string         `json:"name"`
+	Parent *reflectNode   `json:"parent"`
+	Kids   []*reflectNode `json:"kids"`
+}
+
+// wrappedDocument hides type of the built-in document so it is matched through Get.
+type wrappedDocument struct {
+	Document
+}
+
+func TestDoesMatchValueCyclic(t *testing.T) {
+	root := &reflectNode{Name: "root"}
+	kid := &reflectNode{Name: "kid", Parent: root}
+	root.Kids = []*reflectNode{kid}
+
+	var tests = []struct {
+		symbol   string
+		query    map[string]interface{}
+		expected bool
+		err      error
+	}{
+		// values not compared as a whole are not converted
+		{"aa", map[string]interface{}{"parent": map[string]interface{}{"$exists": true}}, true, nil},
+		{"ab", map[string]interface{}{"parent.name": "root"}, true, nil},
+		{"ac", map[string]interface{}{"parent.kids.0.parent.kids.*.name": "kid"}, true, nil},
+		{"ad", map[string]interface{}{"parent.kids.*": map[string]interface{}{"$exists": true}}, true, nil},
+		// cyclic value compared as a whole is reported
+		{"ba", map[string]interface{}{"parent": nil}, false, ErrCyclicValue},
+		{"bb", map[string]interface{}{"parent.kids": map[string]interface{}{"$contains": "x"}}, false, ErrCyclicValue},
+		{"bc", map[string]interface{}{"parent.kids": []interface{}{1}}, false, ErrCyclicValue},
+		{"bd", map[string]interface{}{"parent.kids.*": map[string]interface{}{"$is": 1}}, false, ErrCyclicValue},
+	}
+
+	for _, tc := range tests {
+		q, err := Compile(tc.query)
+		if err != nil {
+			t.Fatalf("[%s] Compile failed: %s", tc.symbol, err)
+		}
+
+		for name, matched := range map[string]func() (bool, error){
+			"DoesMatchValue":    func() (bool, error) { return DoesMatchValue(tc.query, kid) },
+			"Query.MatchValue":  func() (bool, error) { return q.MatchValue(kid) },
+			"DoesMatchDocument": func() (bool, error) { return DoesMatchDocument(tc.query, wrappedDocument{ValueDocument{Value: kid}}) },
+		} {
+			result, err := matched()
+			if result != tc.expected || !errors.Is(err, tc.err) || (tc.err == nil && err != nil) {
+				t.Errorf("[%s|%s] Mismatch => expected: %t (err: %v), have: %t (err: %v)", tc.symbol, name, tc.expected, tc.err, result, err)
+			}
+			if errors.Is(err, ErrInvalidQuery) {
+				t.Errorf("[%s|%s] Cyclic value reported as invalid query: %v", tc.symbol, name, err)
+			}
+		}
+
+		// explanation shows cyclic values as nil
+		if _, err := q.Explain(map[string]interface{}{"parent": root}); err != nil && !errors.Is(err, ErrCyclicValue) {
+			t.Errorf("[%s] Unexpected Explain error: %v", tc.symbol, err)
+		}
+	}
+}
+
+type reflectNumbers struct {
+	N uint64
+	I int64
+	F float32
+}
+
+func TestDoesMatchValueNumbers(t *testing.T) {
+	var tests = []struct {
+		symbol   string
+		query    map[string]interface{}
+		value    reflectNumbers
+		expected bool
+	}{
+		{"aa", map[string]interface{}{"N": uint64(math.MaxUint64 - 1)}, reflectNumbers{N: math.MaxUint64 - 1}, true},
+		{"ab", map[string]interface{}{"N": uint64(math.MaxUint64)}, reflectNumbers{N: math.MaxUint64 - 1}, false},
+		{"ac", map[string]interface{}{"N": map[string]interface{}{"$gt": uint64(math.MaxUint64)}}, reflectNumbers{N: math.MaxUint64}, false},
+		{"ad", map[string]interface{}{"N": map[string]interface{}{"$lt": float64(math.MaxUint64)}}, reflectNumbers{N: math.MaxUint64}, true},
+		{"ba", map[string]interface{}{"I": int64(math.MaxInt64 - 1)}, reflectNumbers{I: math.MaxInt64}, false},
+		{"bb", map[string]interface{}{"I": map[string]interface{}{"$gte": int64(math.MaxInt64)}}, reflectNumbers{I: math.MaxInt64}, true},
+		{"bc", map[string]interface{}{"I": -7}, reflectNumbers{I: -7}, true},
+		{"ca", map[string]interface{}{"F": 0.1}, reflectNumbers{F: 0.1}, true},
+	}
+
+	for _, tc := range tests {
+		q, err := Compile(tc.query)
+		if err != nil {
+			t.Fatalf("[%s] Compile failed: %s", tc.symbol, err)
+		}
+		for name, matched := range map[string]func() (bool, error){
+			"DoesMatchValue":   func() (bool, error) { return DoesMatchValue(tc.query, tc.value) },
+			"Query.MatchValue": func() (bool, error) { return q.MatchValue(&tc.value) },
+		} {
+			result, err := matched()
+			if err != nil || result != tc.expected {
+				t.Errorf("[%s|%s] Mismatch => expected: %t, have: %t (err: %v)", tc.symbol, name, tc.expected, result, err)
+			}
+		}
+	}
+}
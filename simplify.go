@@ -0,0 +1,252 @@
+package gjsonquery
+
+// Simplify rewrites query into normalized form with the same meaning:
+//   - double negations ("!!$is", "$not" of "$not") are removed and negations are pushed down to comparators,
+//   - nested "$and" and "$or" are flattened, "$and"/"$or" with one child is replaced by the child,
+//   - "$in" with one element becomes "$is", alternatives of "$is"/"$in" on the same column are merged into one "$in"
+//     (negated ones joined with AND likewise into one "!$in"),
+//   - constants are folded: empty "$and" always matches, empty "$or" and empty "$in" never match.
+//
+// The result is the canonical structure (see Query.MarshalJSON) of the simplified query,
+// an empty object for a query which always matches and {"$or": {}} for one which never does.
+// Results of DoesMatch are preserved, but the error reported for data which both fails and errors may differ.
+// Malformed query is reported with *ValidationError, see Validate.
+func Simplify(query interface{}) (interface{}, error) {
+	return defaultMatcher.Simplify(query)
+}
+
+// Simplify rewrites query using Matcher configuration, see Simplify.
+func (m *Matcher) Simplify(query interface{}) (interface{}, error) {
+	q, err := m.Compile(query)
+	if err != nil {
+		return nil, err
+	}
+	return canonicalAnd(simplifyNode(m.opts, q.root, false)), nil
+}
+
+// constants are represented by empty logical nodes: AND of nothing always matches, OR of nothing never does
+func isAlways(n node) bool {
+	and, ok := n.(*andNode)
+	return ok && len(and.children) == 0
+}
+
+func isNever(n node) bool {
+	or, ok := n.(*orNode)
+	return ok && len(or.children) == 0
+}
+
+func constantNode(matched bool) node {
+	if matched {
+		return &andNode{}
+	}
+	return &orNode{}
+}
+
+// simplifyNode returns simplified copy of the tree, negated tells if the node is negated (odd number of negations).
+// Negations are pushed down with De Morgan's laws so the result contains no notNode.
+func simplifyNode(o *options, n node, negated bool) node {
+	switch v := n.(type) {
+	case *notNode:
+		return simplifyNode(o, v.child, !negated)
+	case *andNode:
+		children := make([]node, len(v.children))
+		for i, child := range v.children {
+			children[i] = simplifyNode(o, child, negated)
+		}
+		if negated {
+			return simplifyOr(o, children)
+		}
+		return simplifyAnd(o, children)
+	case *orNode:
+		children := make([]node, len(v.children))
+		for i, child := range v.children {
+			children[i] = simplifyNode(o, child, negated)
+		}
+		if negated {
+			return simplifyAnd(o, children)
+		}
+		return simplifyOr(o, children)
+	case *comparatorNode:
+		c := v.check
+		c.cmp.negated = c.cmp.negated != negated
+		return &comparatorNode{check: c}
+	case *columnNode:
+		return simplifyColumn(o, v, negated)
+	}
+	return n
+}
+
+// simplifyColumn folds constant checks and shortens "$in" with one element.
+// Checks of a column are joined with AND, so negated column with more checks becomes OR of negated checks.
+func simplifyColumn(o *options, n *columnNode, negated bool) node {
+	// column without checks always matches
+	if len(n.checks) == 0 {
+		return constantNode(!negated)
+	}
+	if negated && len(n.checks) > 1 {
+		children := make([]node, len(n.checks))
+		for i := range n.checks {
+			single := *n
+			single.checks = n.checks[i : i+1]
+			children[i] = simplifyColumn(o, &single, true)
+		}
+		return simplifyOr(o, children)
+	}
+
+	simplified := *n
+	simplified.checks = make([]check, 0, len(n.checks))
+	for _, c := range n.checks {
+		c.cmp.negated = c.cmp.negated != negated
+		if list, ok := inList(c); ok {
+			unique := uniqueValues(list, o.strictNumbers)
+			switch {
+			case len(unique) == 0:
+				// nothing is in empty list
+				if !c.cmp.negated {
+					return constantNode(false)
+				}
+				continue
+			case len(unique) < len(list) || c.cmp.cType == COMPARATOR_IN && len(unique) == 1:
+				c = simpleCheck(o, c.cmp.negated, unique)
+			}
+		}
+		simplified.checks = append(simplified.checks, c)
+	}
+	// every check was always matching
+	if len(simplified.checks) == 0 {
+		return constantNode(true)
+	}
+	return &simplified
+}
+
+// simplifyAnd flattens nested AND, folds constants and merges negated "$is"/"$in" of the same column into one "!$in".
+func simplifyAnd(o *options, children []node) node {
+	var flat []node
+	for _, child := range children {
+		switch {
+		case isNever(child):
+			return child
+		case isAlways(child):
+		default:
+			if and, ok := child.(*andNode); ok {
+				flat = append(flat, and.children...)
+			} else {
+				flat = append(flat, child)
+			}
+		}
+	}
+	flat = mergeInLists(o, flat, true)
+	if len(flat) == 1 {
+		return flat[0]
+	}
+	return &andNode{children: flat}
+}
+
+// simplifyOr flattens nested OR, folds constants and merges "$is"/"$in" of the same column into one "$in".
+func simplifyOr(o *options, children []node) node {
+	var flat []node
+	for _, child := range children {
+		switch {
+		case isAlways(child):
+			return child
+		case isNever(child):
+		default:
+			if or, ok := child.(*orNode); ok {
+				flat = append(flat, or.children...)
+			} else {
+				flat = append(flat, child)
+			}
+		}
+	}
+	flat = mergeInLists(o, flat, false)
+	if len(flat) == 1 {
+		return flat[0]
+	}
+	return &orNode{children: flat}
+}
+
+// mergeInLists joins single "$is"/"$in" checks of the same column with given negation into one check.
+// It is valid for OR of positive checks and for AND of negated checks (including wildcard paths), both are "$in" of the union.
+func mergeInLists(o *options, children []node, negated bool) []node {
+	var (
+		merged  []node
+		columns = make(map[string]int)
+	)
+	for _, child := range children {
+		column, ok := child.(*columnNode)
+		if !ok || len(column.checks) != 1 || column.checks[0].cmp.negated != negated {
+			merged = append(merged, child)
+			continue
+		}
+		list, ok := inList(column.checks[0])
+		if !ok {
+			merged = append(merged, child)
+			continue
+		}
+
+		i, seen := columns[column.column]
+		if !seen {
+			columns[column.column] = len(merged)
+			merged = append(merged, child)
+			continue
+		}
+		previous := merged[i].(*columnNode)
+		union, _ := inList(previous.checks[0])
+		joined := *previous
+		joined.checks = []check{simpleCheck(o, negated, uniqueValues(append(union, list...), o.strictNumbers))}
+		merged[i] = &joined
+	}
+	return merged
+}
+
+// inList returns values accepted by "$is" with scalar or "$in" with list of scalars.
+func inList(c check) ([]interface{}, bool) {
+	switch c.cmp.cType {
+	case COMPARATOR_IS:
+		if isScalar(c.expectation) {
+			return []interface{}{c.expectation}, true
+		}
+	case COMPARATOR_IN:
+		list, _ := c.expectation.([]interface{})
+		for _, value := range list {
+			if !isScalar(value) {
+				return nil, false
+			}
+		}
+		// copy so merging does not modify the query
+		return append([]interface{}(nil), list...), true
+	}
+	return nil, false
+}
+
+// simpleCheck creates "$is" for one value and "$in" for more values.
+func simpleCheck(o *options, negated bool, list []interface{}) check {
+	cmp := comparator{cType: COMPARATOR_IN, negated: negated}
+	var expectation interface{} = list
+	if len(list) == 1 {
+		cmp.cType, expectation = COMPARATOR_IS, list[0]
+	}
+	// list of scalars is always a valid expectation
+	c, _ := compileCheck(o, cmp, cmp.String(), expectation, "")
+	return c
+}
+
+// uniqueValues removes values equal to an earlier one (the same way as "$in" compares them).
+func uniqueValues(list []interface{}, strict bool) []interface{} {
+	unique := make([]interface{}, 0, len(list))
+	for _, value := range list {
+		if !containsValue(unique, value, strict) {
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
+
+func containsValue(list []interface{}, value interface{}, strict bool) bool {
+	for _, e := range list {
+		if equal(e, value, strict) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,212 @@
+package gjsonquery_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	. "github.com/szpakas/gjsonquery"
+)
+
+func TestSimplify(t *testing.T) {
+	var tests = []struct {
+		symbol   string
+		query    string
+		expected string
+	}{
+		{"aa", `{"a": 1, "b": {"$gte": 18, "$lt": 65}}`, `{"a":1,"b":{"$gte":18,"$lt":65}}`},
+		// negations
+		{"ba", `{"a": {"!!$is": 1}}`, `{"a":1}`},
+		{"bb", `{"$not": {"$not": {"a": 1}}}`, `{"a":1}`},
+		{"bc", `{"$not": {"a": 1, "b": {"$gt": 2}}}`, `{"$or":{"a":{"!$is":1},"b":{"!$gt":2}}}`},
+		{"bd", `{"!$or": [{"a": 1}, {"b": {"$exists": true}}]}`, `{"a":{"!$is":1},"b":{"!$exists":true}}`},
+		{"be", `{"!a": {"$gte": 18, "$lt": 65}}`, `{"$or":[{"a":{"!$gte":18}},{"a":{"!$lt":65}}]}`},
+		{"bf", `{"$not": {"$gt": 1}}`, `{"!$gt":1}`},
+		// flattening
+		{"ca", `{"$and": [{"a": 1}, {"$and": {"b": 2}}]}`, `{"a":1,"b":2}`},
+		{"cb", `{"$or": [{"$or": {"a": 1}}, {"b": {"$gt": 2}}]}`, `{"$or":{"a":1,"b":{"$gt":2}}}`},
+		{"cc", `{"$or": {"a": {"$gt": 1}}}`, `{"a":{"$gt":1}}`},
+		{"cd", `{"$or": [{"a": {"$gt": 1}, "b": 1}]}`, `{"a":{"$gt":1},"b":1}`},
+		// "$in" lists
+		{"da", `{"a": {"$in": [1]}}`, `{"a":1}`},
+		{"db", `{"a": {"!$in": [1]}}`, `{"a":{"!$is":1}}`},
+		{"dc", `{"a": [1, 2, 1.0]}`, `{"a":[1,2]}`},
+		{"dd", `{"$or": [{"a": 1}, {"a": [2, 3]}, {"a": {"$in": [3, 4]}}]}`, `{"a":[1,2,3,4]}`},
+		{"de", `{"$or": [{"a": 1}, {"b": 2}, {"a": 3}]}`, `{"$or":{"a":[1,3],"b":2}}`},
+		{"df", `{"!a": 1, "a": {"$not": [2, 3]}}`, `{"a":{"!$in":[1,2,3]}}`},
+		{"dg", `{"a": 1, "$and": {"a": 2}}`, `[{"a":1},{"a":2}]`},
+		{"dh", `{"$or": [{"a": 1}, {"a": {"!$is": 2}}]}`, `{"$or":[{"a":1},{"a":{"!$is":2}}]}`},
+		{"di", `{"$or": [{"items.*.sku": "x"}, {"items.*.sku": "y"}]}`, `{"items.*.sku":["x","y"]}`},
+		// constants
+		{"ea", `{}`, `{}`},
+		{"eb", `{"$and": []}`, `{}`},
+		{"ec", `{"$or": []}`, `{"$or":{}}`},
+		{"ed", `{"a": 1, "$or": []}`, `{"$or":{}}`},
+		{"ee", `{"a": 1, "b": {"$in": []}}`, `{"$or":{}}`},
+		{"ef", `{"a": 1, "b": {"!$in": []}}`, `{"a":1}`},
+		{"eg", `{"$or": [{"a": 1}, {"$and": []}]}`, `{}`},
+		{"eh", `{"$or": [{"a": 1}, {"$or": []}]}`, `{"a":1}`},
+		{"ei", `{"$not": {}}`, `{"$or":{}}`},
+		{"ej", `{"!$or": []}`, `{}`},
+		{"ek", `{"a": {"$gt": 1, "!$in": []}}`, `{"a":{"$gt":1}}`},
+		{"el", `{"a": {}}`, `{}`},
+		{"em", `{"!a": {}}`, `{"$or":{}}`},
+		{"en", `{"$not": {"a": {}}}`, `{"$or":{}}`},
+		{"eo", `{"b": 1, "!a": {}}`, `{"$or":{}}`},
+		{"ep", `{"$or": [{"b": 1}, {"!a": {}}]}`, `{"b":1}`},
+	}
+
+	for _, tc := range tests {
+		var query interface{}
+		if err := json.Unmarshal([]byte(tc.query), &query); err != nil {
+			t.Fatalf("[%s] invalid test query: %v", tc.symbol, err)
+		}
+		simplified, err := Simplify(query)
+		if err != nil {
+			t.Errorf("[%s] unexpected error: %v", tc.symbol, err)
+			continue
+		}
+		encoded, err := json.Marshal(simplified)
+		if err != nil {
+			t.Errorf("[%s] unexpected marshal error: %v", tc.symbol, err)
+			continue
+		}
+		if string(encoded) != tc.expected {
+			t.Errorf("[%s] Mismatch\nexpected => %s\n    have => %s", tc.symbol, tc.expected, encoded)
+		}
+
+		// simplified query does not simplify further
+		again, _ := Simplify(simplified)
+		if encodedAgain, _ := json.Marshal(again); string(encodedAgain) != string(encoded) {
+			t.Errorf("[%s] Not idempotent\nfirst  => %s\nsecond => %s", tc.symbol, encoded, encodedAgain)
+		}
+	}
+}
+
+func TestSimplifyInvalid(t *testing.T) {
+	_, err := Simplify(map[string]interface{}{"$or": []interface{}{map[string]interface{}{"a": map[string]interface{}{"$bogus": 1}}}})
+	var vErr *ValidationError
+	if !errors.As(err, &vErr) || vErr.Path != "/$or/0/a/$bogus" || !errors.Is(err, ErrUnknownComparator) {
+		t.Errorf("Mismatch on error => have: %v", err)
+	}
+}
+
+func TestSimplifyDoesMatch(t *testing.T) {
+	for _, tDef := range doesMatchTests {
+		simplified, err := Simplify(tDef.query)
+		if err != nil {
+			continue
+		}
+		for _, tCase := range tDef.tests {
+			expected, err := DoesMatch(tDef.query, tCase.data)
+			if err != nil {
+				continue
+			}
+			result, err := DoesMatch(simplified, tCase.data)
+			// the error reported may differ only when data both fails and errors
+			if err != nil && expected {
+				t.Errorf("[%s|%s] Unexpected error: %v", tDef.symbol, tCase.symbol, err)
+			}
+			if err == nil && result != expected {
+				t.Errorf("[%s|%s] Mismatch => expected: %#+v, have: %#+v", tDef.symbol, tCase.symbol, expected, result)
+			}
+		}
+	}
+}
+
+// randomQuery builds query of comparators which never error on randomData.
+func randomQuery(r *rand.Rand, depth int) map[string]interface{} {
+	query := make(map[string]interface{})
+	for i := r.Intn(3); i >= 0; i-- {
+		key, value := randomEntry(r, depth)
+		query[key] = value
+	}
+	return query
+}
+
+func randomEntry(r *rand.Rand, depth int) (string, interface{}) {
+	negation := []string{"", "", "!", "!!"}[r.Intn(4)]
+	if depth > 0 && r.Intn(3) == 0 {
+		children := make([]interface{}, r.Intn(3))
+		for i := range children {
+			children[i] = randomQuery(r, depth-1)
+		}
+		switch r.Intn(3) {
+		case 0:
+			return negation + "$and", children
+		case 1:
+			return negation + "$or", children
+		}
+		return negation + "$not", randomQuery(r, depth-1)
+	}
+
+	column := []string{"a", "b", "c", "l.*"}[r.Intn(4)]
+	// column without comparators always matches
+	comparators := make(map[string]interface{})
+	for i := r.Intn(4) - 1; i >= 0; i-- {
+		cmpNegation := []string{"", "", "!", "!!"}[r.Intn(4)]
+		switch r.Intn(5) {
+		case 0:
+			comparators[cmpNegation+"$is"] = randomScalar(r)
+		case 1, 2:
+			list := make([]interface{}, r.Intn(4))
+			for i := range list {
+				list[i] = randomScalar(r)
+			}
+			comparators[cmpNegation+"$in"] = list
+		case 3:
+			comparators[cmpNegation+"$not"] = randomScalar(r)
+		case 4:
+			comparators[cmpNegation+"$exists"] = r.Intn(2) == 0
+		}
+	}
+	return negation + column, comparators
+}
+
+func randomScalar(r *rand.Rand) interface{} {
+	return []interface{}{nil, 1, 2, 3, 2.0}[r.Intn(5)]
+}
+
+func randomData(r *rand.Rand) map[string]interface{} {
+	data := make(map[string]interface{})
+	for _, key := range []string{"a", "b", "c"} {
+		if r.Intn(4) > 0 {
+			data[key] = randomScalar(r)
+		}
+	}
+	if r.Intn(4) > 0 {
+		list := make([]interface{}, r.Intn(3))
+		for i := range list {
+			list[i] = randomScalar(r)
+		}
+		data["l"] = list
+	}
+	return data
+}
+
+func TestSimplifyEquivalence(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		query := randomQuery(r, 3)
+		simplified, err := Simplify(query)
+		if err != nil {
+			t.Fatalf("[%d] unexpected error: %v", i, err)
+		}
+		for j := 0; j < 20; j++ {
+			data := randomData(r)
+			expected, err := DoesMatch(query, data)
+			if err != nil {
+				t.Fatalf("[%d] unexpected error of the original query: %v", i, err)
+			}
+			result, err := DoesMatch(simplified, data)
+			if err != nil || result != expected {
+				encodedQuery, _ := json.Marshal(query)
+				encodedSimplified, _ := json.Marshal(simplified)
+				t.Fatalf("[%d] Mismatch on %s\nquery      => %s\nsimplified => %s\nexpected: %t, have: %t (%v)",
+					i, fmt.Sprint(data), encodedQuery, encodedSimplified, expected, result, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package gjsonquery
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Names of the evaluation steps reported in TraceEvent.Op.
+const (
+	TRACE_MATCH_VALUE       = "matchValue"
+	TRACE_DETECT_COMPARATOR = "detectComparator"
+	TRACE_MATCH_COMPARATOR  = "matchComparator"
+	TRACE_FETCH_VALUE       = "fetchValue"
+)
+
+// TraceEvent describes single step of query evaluation.
+// Fields which are not relevant for the step are left empty.
+type TraceEvent struct {
+	// Op is one of TRACE_* step names.
+	Op string
+	// Column is the column being matched.
+	Column string
+	// Comparator is the comparator name, e.g. "!$in".
+	Comparator string
+	// Value is the value fetched from data.
+	Value interface{}
+	// Found tells if Value exists in data.
+	Found bool
+	// Expectation is the expected value from the query.
+	Expectation interface{}
+	// Result is the outcome of the step, for TRACE_DETECT_COMPARATOR it tells if comparator is known.
+	Result bool
+	// Err is the error reported by the step.
+	Err error
+}
+
+// Tracer receives events emitted during query evaluation.
+// Tracer is called synchronously from the goroutine doing the evaluation.
+type Tracer interface {
+	Trace(event TraceEvent)
+}
+
+// TracerFunc is an adapter allowing use of ordinary functions as Tracer.
+type TracerFunc func(event TraceEvent)
+
+// Trace calls f(event).
+func (f TracerFunc) Trace(event TraceEvent) {
+	f(event)
+}
+
+// WithTracer sets tracer receiving evaluation events, nil disables tracing (default).
+func WithTracer(tracer Tracer) Option {
+	return func(o *options) {
+		o.tracer = tracer
+	}
+}
+
+// NewSlogTracer returns Tracer which logs every event with logger on debug level.
+func NewSlogTracer(logger *slog.Logger) Tracer {
+	return TracerFunc(func(event TraceEvent) {
+		if !logger.Enabled(context.Background(), slog.LevelDebug) {
+			return
+		}
+		attrs := []slog.Attr{slog.Bool("result", event.Result)}
+		if event.Column != "" {
+			attrs = append(attrs, slog.String("column", event.Column))
+		}
+		if event.Comparator != "" {
+			attrs = append(attrs, slog.String("comparator", event.Comparator))
+		}
+		switch event.Op {
+		case TRACE_MATCH_COMPARATOR, TRACE_FETCH_VALUE:
+			attrs = append(attrs, slog.Any("value", event.Value), slog.Bool("found", event.Found))
+		}
+		switch event.Op {
+		case TRACE_MATCH_VALUE, TRACE_MATCH_COMPARATOR:
+			attrs = append(attrs, slog.Any("expectation", event.Expectation))
+		}
+		if event.Err != nil {
+			attrs = append(attrs, slog.Any("error", event.Err))
+		}
+		logger.LogAttrs(context.Background(), slog.LevelDebug, "gjsonquery: "+event.Op, attrs...)
+	})
+}
+
+// trace passes event to the configured tracer.
+// Callers check o.tracer first to avoid building events when tracing is disabled.
+func (o *options) trace(event TraceEvent) {
+	if o.tracer != nil {
+		o.tracer.Trace(event)
+	}
+}
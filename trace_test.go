@@ -0,0 +1,130 @@
+package gjsonquery_test
+
+import (
+	"bytes"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/szpakas/gjsonquery"
+)
+
+func TestTracer(t *testing.T) {
+	query := map[string]interface{}{"a": map[string]interface{}{"!$gt": 5}}
+	data := map[string]interface{}{"a": 2}
+
+	var events []TraceEvent
+	tracer := TracerFunc(func(e TraceEvent) { events = append(events, e) })
+
+	expected := []TraceEvent{
+		{Op: TRACE_DETECT_COMPARATOR, Comparator: "!$gt", Result: true},
+		{Op: TRACE_FETCH_VALUE, Column: "a", Value: 2, Found: true},
+		{Op: TRACE_MATCH_COMPARATOR, Comparator: "!$gt", Value: 2, Found: true, Expectation: 5, Result: true},
+		{Op: TRACE_MATCH_VALUE, Column: "a", Expectation: map[string]interface{}{"!$gt": 5}, Result: true},
+	}
+
+	matched, err := NewMatcher(WithTracer(tracer)).DoesMatch(query, data)
+	if err != nil || !matched {
+		t.Fatalf("[aa] Mismatch => expected: true, have: %t (err: %v)", matched, err)
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("[aa] Mismatch on events\nexpected => %#+v\n    have => %#+v", expected, events)
+	}
+
+	// compiled query reports comparator as used in query with result after negation
+	q, err := Compile(query)
+	if err != nil {
+		t.Fatalf("[ba] Compile failed: %s", err)
+	}
+	events = nil
+	matched, err = q.MatchWithTracer(data, tracer)
+	if err != nil || !matched {
+		t.Fatalf("[ba] Mismatch => expected: true, have: %t (err: %v)", matched, err)
+	}
+	expected = []TraceEvent{
+		{Op: TRACE_FETCH_VALUE, Column: "a", Value: 2, Found: true},
+		{Op: TRACE_MATCH_COMPARATOR, Comparator: "!$gt", Value: 2, Found: true, Expectation: 5, Result: true},
+		{Op: TRACE_MATCH_VALUE, Column: "a", Result: true},
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("[ba] Mismatch on events\nexpected => %#+v\n    have => %#+v", expected, events)
+	}
+
+	// fast paths ("$in" set, "$regex") and wildcard paths are reported as well
+	q, err = Compile(map[string]interface{}{
+		"a":     map[string]interface{}{"$in": []interface{}{1, 2}, "!$is": 3},
+		"b":     map[string]interface{}{"$regex": "^x"},
+		"c.*.d": map[string]interface{}{"!$not": 4},
+	})
+	if err != nil {
+		t.Fatalf("[bc] Compile failed: %s", err)
+	}
+	events = nil
+	data = map[string]interface{}{"a": 1, "b": "xy", "c": []interface{}{map[string]interface{}{"d": 4}}}
+	matched, err = q.MatchWithTracer(data, tracer)
+	if err != nil || !matched {
+		t.Fatalf("[bc] Mismatch => expected: true, have: %t (err: %v)", matched, err)
+	}
+	expected = []TraceEvent{
+		{Op: TRACE_FETCH_VALUE, Column: "a", Value: 1, Found: true},
+		{Op: TRACE_MATCH_COMPARATOR, Comparator: "!$is", Value: 1, Found: true, Expectation: 3, Result: true},
+		{Op: TRACE_MATCH_COMPARATOR, Comparator: "$in", Value: 1, Found: true, Expectation: []interface{}{1, 2}, Result: true},
+		{Op: TRACE_MATCH_VALUE, Column: "a", Result: true},
+		{Op: TRACE_FETCH_VALUE, Column: "b", Value: "xy", Found: true},
+		{Op: TRACE_MATCH_COMPARATOR, Comparator: "$regex", Value: "xy", Found: true, Expectation: "^x", Result: true},
+		{Op: TRACE_MATCH_VALUE, Column: "b", Result: true},
+		{Op: TRACE_FETCH_VALUE, Column: "c.*.d", Value: []interface{}{4}, Found: true},
+		{Op: TRACE_MATCH_COMPARATOR, Comparator: "!$not", Value: []interface{}{4}, Found: true, Expectation: 4, Result: true},
+		{Op: TRACE_MATCH_VALUE, Column: "c.*.d", Result: true},
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("[bc] Mismatch on events\nexpected => %#+v\n    have => %#+v", expected, events)
+	}
+
+	// tracing is scoped to the single evaluation
+	events = nil
+	if _, err := q.Match(data); err != nil || len(events) != 0 {
+		t.Errorf("[bb] Query without tracer should not emit events, have: %#+v (err: %v)", events, err)
+	}
+}
+
+func TestTracerReportsErrors(t *testing.T) {
+	var last TraceEvent
+	tracer := TracerFunc(func(e TraceEvent) { last = e })
+
+	_, err := NewMatcher(WithTracer(tracer)).DoesMatch(map[string]interface{}{"a": map[string]interface{}{"$gt": 5}}, map[string]interface{}{"a": "x"})
+	if !sameError(ErrTypeMismatch, err) {
+		t.Fatalf("Mismatch on error => expected: %#+v, have: %#+v", ErrTypeMismatch, err)
+	}
+	if last.Op != TRACE_MATCH_VALUE || last.Err != err || last.Result {
+		t.Errorf("Mismatch on last event, have: %#+v", last)
+	}
+}
+
+func TestSlogTracer(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	matched, err := NewMatcher(WithTracer(NewSlogTracer(logger))).DoesMatch(map[string]interface{}{"a": "x"}, map[string]interface{}{"a": "x"})
+	if err != nil || !matched {
+		t.Fatalf("Mismatch => expected: true, have: %t (err: %v)", matched, err)
+	}
+
+	for _, line := range []string{
+		`level=DEBUG msg="gjsonquery: fetchValue" result=false column=a value=x found=true`,
+		`level=DEBUG msg="gjsonquery: matchComparator" result=true comparator=$is value=x found=true expectation=x`,
+		`level=DEBUG msg="gjsonquery: matchValue" result=true column=a expectation=x`,
+	} {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("Log line not found => expected: %s\nhave:\n%s", line, buf.String())
+		}
+	}
+
+	// nothing is logged above debug level
+	buf.Reset()
+	logger = slog.New(slog.NewTextHandler(&buf, nil))
+	if _, err := NewMatcher(WithTracer(NewSlogTracer(logger))).DoesMatch(map[string]interface{}{"a": "x"}, map[string]interface{}{"a": "x"}); err != nil || buf.Len() != 0 {
+		t.Errorf("Unexpected log output: %q (err: %v)", buf.String(), err)
+	}
+}
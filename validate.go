@@ -0,0 +1,38 @@
+package gjsonquery
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidationError describes a malformed node of a query.
+type ValidationError struct {
+	// Path is a JSON Pointer (RFC 6901) to the offending node, e.g. "/$or/1/b/$bogus".
+	// Empty path points to the whole query.
+	Path string
+	// Err is the reason why the node is invalid, usually *QueryError.
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid query at %q: %s", e.Path, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Validate checks the whole query up front using the same rules as DoesMatch.
+// Unlike DoesMatch it does not stop on branches which would not be reached for particular data.
+// First problem found is reported as *ValidationError.
+func Validate(query interface{}) error {
+	_, err := Compile(query)
+	return err
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// pointerAppend adds reference token to the JSON Pointer.
+func pointerAppend(pointer, token string) string {
+	return pointer + "/" + pointerEscaper.Replace(token)
+}
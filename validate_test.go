@@ -0,0 +1,82 @@
+package gjsonquery_test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/szpakas/gjsonquery"
+)
+
+func TestValidate(t *testing.T) {
+	type testCase struct {
+		symbol string
+		query  interface{}
+		path   string
+		err    error
+	}
+
+	var tests = []testCase{
+		// valid
+		{"AA", map[string]interface{}{"a": 100}, "", nil},
+		{"AB", map[string]interface{}{}, "", nil},
+		{"AC", map[string]interface{}{"a": map[string]interface{}{"$gte": 18, "!$lt": 65}}, "", nil},
+		{"AD", map[string]interface{}{"!$and": []interface{}{map[string]interface{}{"a": 101}}}, "", nil},
+		{"AE", map[string]interface{}{"": 100}, "", nil},
+		// invalid on root
+		{"BA", 101, "", ErrUnknownQueryType},
+		{"BB", map[string]interface{}{"$unknown": 101}, "/$unknown", ErrUnknownComparator},
+		{"BC", map[string]interface{}{"$and": 101}, "/$and", ErrUnknownQueryType},
+		{"BD", map[string]interface{}{"!$or": 101}, "/!$or", ErrUnknownQueryType},
+		// invalid deep in the tree, in branch which might not be reached during matching
+		{"CA", map[string]interface{}{
+			"$or": []interface{}{
+				map[string]interface{}{"a": 1},
+				map[string]interface{}{"b": map[string]interface{}{"$bogus": 1}},
+			},
+		}, "/$or/1/b/$bogus", ErrUnknownComparator},
+		{"CB", map[string]interface{}{
+			"$not": map[string]interface{}{
+				"$and": []interface{}{
+					map[string]interface{}{"a": 1},
+					101,
+				},
+			},
+		}, "/$not/$and/1", ErrUnknownQueryType},
+		{"CC", map[string]interface{}{"a.b": map[string]interface{}{"$in": 101}}, "/a.b/$in", ErrUnknownExpectedType},
+		{"CD", map[string]interface{}{"a": map[string]interface{}{"$lt": true}}, "/a/$lt", ErrUnknownExpectedType},
+		{"CE", map[string]interface{}{"a": map[int]interface{}{123: 101}}, "/a", ErrNotAMap},
+		// escaping of reference tokens
+		{"DA", map[string]interface{}{"a/b~c": map[string]interface{}{"$bogus": 1}}, "/a~1b~0c/$bogus", ErrUnknownComparator},
+	}
+
+	for _, tc := range tests {
+		err := Validate(tc.query)
+
+		if tc.err == nil {
+			if err != nil {
+				t.Errorf("[%s] Unexpected error: %s", tc.symbol, err)
+			}
+			continue
+		}
+
+		var vErr *ValidationError
+		if !errors.As(err, &vErr) {
+			t.Errorf("[%s] Expected *ValidationError, have: %#+v", tc.symbol, err)
+			continue
+		}
+		if vErr.Path != tc.path {
+			t.Errorf("[%s] Mismatch on path => expected: %q, have: %q", tc.symbol, tc.path, vErr.Path)
+		}
+		if !sameError(tc.err, vErr.Err) {
+			t.Errorf("[%s] Mismatch on reason => expected: %#+v, have: %#+v", tc.symbol, tc.err, vErr.Err)
+		}
+	}
+}
+
+func TestValidationErrorMessage(t *testing.T) {
+	err := Validate(map[string]interface{}{"a": map[string]interface{}{"$bogus": 1}})
+	expected := `invalid query at "/a/$bogus": matchComparator: unknown comparator (column: "a", comparator: "$bogus")`
+	if err == nil || err.Error() != expected {
+		t.Errorf("Mismatch => expected: %q, have: %v", expected, err)
+	}
+}